@@ -2,20 +2,19 @@ package cmd
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"errors"
 	"sort"
 	"strconv"
+	"sync"
 
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/cobra"
 )
 
 type Connector struct {
-	Id      int
-	Name    string
-	Details ConnectorDetails
+	Id      int              `json:"id"`
+	Name    string           `json:"name"`
+	Details ConnectorDetails `json:"details"`
 }
 
 func (c Connector) PollInterval() string {
@@ -30,29 +29,25 @@ func (c Connector) PollInterval() string {
 	return FormatPollInterval((pollIntervalMs))
 }
 
-func GetConnectorsList(host string, port string) []string {
-	url := fmt.Sprintf("http://%s:%s/connectors", host, port)
-	log.Debug("getting connectors using URL: ", url)
-
-	resp, err := http.Get(url)
-	cobra.CheckErr(err)
-
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	cobra.CheckErr(err)
-
-	var connectors []string
-	json.Unmarshal(bodyBytes, &connectors)
+func GetConnectorsList(client *connect.Client) ([]string, error) {
+	connectors, err := client.ListConnectors()
+	if err != nil {
+		return nil, err
+	}
 
 	sort.Strings(connectors)
 	log.Debug("connectors found: ", connectors)
-	return connectors
+	return connectors, nil
 }
 
 // GetConnectorsMap returns a map of connectorId -> connectorName
 // The connectorId is based on the alphabetically sorted connectorNames
-func GetConnectorsMap(host string, port string) map[int]Connector {
+func GetConnectorsMap(client *connect.Client) (map[int]Connector, error) {
 
-	connectors := GetConnectorsList(host, port)
+	connectors, err := GetConnectorsList(client)
+	if err != nil {
+		return nil, err
+	}
 
 	connectorsMap := make(map[int]Connector)
 
@@ -62,27 +57,199 @@ func GetConnectorsMap(host string, port string) map[int]Connector {
 
 	log.Debug("connectorsMap: ", connectorsMap)
 
-	return connectorsMap
+	return connectorsMap, nil
+}
+
+// GetConnectorsDetails gets the status, config and task configs of each connector,
+// the connectors keep their existing connectorIds
+func GetConnectorsDetails(client *connect.Client, connectors map[int]Connector) (map[int]Connector, error) {
+	connectors, err := GetConnectorsStatus(client, connectors)
+	if err != nil {
+		return nil, err
+	}
+
+	return addTaskConfigs(client, connectors)
+}
+
+// GetConnectorsWithStatus gets the connectors whose name contains the filter with their status and config
+// but not the config of their tasks. If the worker supports ?expand this is a single request that also
+// lists the connectors, otherwise they are listed and then each connector is requested individually.
+func GetConnectorsWithStatus(client *connect.Client, filter string) (map[int]Connector, error) {
+	expanded, err := client.ListConnectorsExpanded()
+	if err != nil && !errors.Is(err, connect.ErrExpandNotSupported) {
+		return nil, err
+	}
+
+	var connectors map[int]Connector
+	if expanded != nil {
+		// the expanded connectors have the same connectorIds as GetConnectorsMap
+		names := make([]string, 0, len(expanded))
+		for name := range expanded {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		connectors = make(map[int]Connector)
+		for i, name := range names {
+			connectors[i] = Connector{Id: i, Name: name}
+		}
+	} else {
+		log.Debug("worker does not support ?expand, getting each connector individually")
+		if connectors, err = GetConnectorsMap(client); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter != "" {
+		connectors = FilterConnectorsByName(connectors, filter)
+	}
+	return connectorsStatus(client, connectors, expanded)
+}
+
+// addTaskConfigs gets the config of the tasks of each connector,
+// the task configs are not included in the expanded connectors so are always requested per connector
+func addTaskConfigs(client *connect.Client, connectors map[int]Connector) (map[int]Connector, error) {
+	return forEachConnector(connectors, func(connector Connector) (Connector, error) {
+		tasksMap, err := GetConnectorTasks(client, connector.Name)
+		if err != nil {
+			return connector, err
+		}
+		for j, task := range connector.Details.Tasks {
+			connector.Details.Tasks[j].Config = tasksMap[task.Id].Config
+		}
+		return connector, nil
+	})
 }
 
-func GetConnectorsDetails(host string, port string, connectors map[int]Connector) map[int]Connector {
-	for connectorId, connector := range connectors {
-		connector.Details = GetConnectorDetails(host, port, connectorId, connector.Name)
-		connectors[connectorId] = connector
+// GetConnectorsStatus gets the status and config of each connector but not the config of their tasks.
+// If the worker supports ?expand this is a single request, otherwise each connector is requested individually.
+func GetConnectorsStatus(client *connect.Client, connectors map[int]Connector) (map[int]Connector, error) {
+	expanded, err := client.ListConnectorsExpanded()
+	if errors.Is(err, connect.ErrExpandNotSupported) {
+		log.Debug("worker does not support ?expand, getting each connector individually")
+	} else if err != nil {
+		return nil, err
+	}
+	return connectorsStatus(client, connectors, expanded)
+}
+
+// connectorsStatus sets the status and config of each connector from the expanded connectors,
+// those that are not expanded are requested individually
+func connectorsStatus(client *connect.Client, connectors map[int]Connector, expanded map[string]connect.ExpandedConnector) (map[int]Connector, error) {
+	return forEachConnector(connectors, func(connector Connector) (Connector, error) {
+		if e, ok := expanded[connector.Name]; ok {
+			connector.Details = newConnectorDetails(e.Status)
+			connector.Details.Config = e.Info.Config
+			return connector, nil
+		}
+
+		log.Debug("getting connector status for ", connector.Id, " ", connector.Name)
+		details, err := GetConnectorStatus(client, connector.Name)
+		if err != nil {
+			return connector, err
+		}
+		details.Config, err = GetConnectorConfig(client, connector.Name)
+		connector.Details = details
+		return connector, err
+	})
+}
+
+// forEachConnector calls fn for each connector using a pool of concurrency workers
+// and stores the returned connector under its existing connectorId
+func forEachConnector(connectors map[int]Connector, fn func(Connector) (Connector, error)) (map[int]Connector, error) {
+	workers := concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	connectorIds := make([]int, 0, len(connectors))
+	for connectorId := range connectors {
+		connectorIds = append(connectorIds, connectorId)
+	}
+	sort.Ints(connectorIds)
+
+	ids := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for connectorId := range ids {
+				mu.Lock()
+				connector := connectors[connectorId]
+				mu.Unlock()
+
+				connector, err := fn(connector)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				connectors[connectorId] = connector
+				mu.Unlock()
+			}
+		}()
 	}
-	return connectors
+
+	for _, connectorId := range connectorIds {
+		ids <- connectorId
+	}
+	close(ids)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return connectors, nil
 }
 
 type ConnectorDetails struct {
-	Name      string
-	Config    map[string]string
-	Connector ConnectorState
-	Tasks     []TaskState
+	Name      string            `json:"name"`
+	Config    map[string]string `json:"config"`
+	Connector ConnectorState    `json:"connector"`
+	Tasks     []TaskState       `json:"tasks"`
+}
+
+// MarshalJSON hides secret config values so they are not written by --output json or yaml
+func (d ConnectorDetails) MarshalJSON() ([]byte, error) {
+	type connectorDetails ConnectorDetails
+	d.Config = cleanseConfig(d.Config, nil)
+	return json.Marshal(connectorDetails(d))
+}
+
+// Failed reports whether the connector or any of its tasks has FAILED
+func (d ConnectorDetails) Failed() bool {
+	if d.Connector.State == "FAILED" {
+		return true
+	}
+	for _, task := range d.Tasks {
+		if task.State == "FAILED" {
+			return true
+		}
+	}
+	return false
+}
+
+// InState reports whether the connector and all of its tasks are in the state,
+// a RUNNING connector without any tasks is still starting them
+func (d ConnectorDetails) InState(state string) bool {
+	if d.Connector.State != state || (state == "RUNNING" && len(d.Tasks) == 0) {
+		return false
+	}
+	for _, task := range d.Tasks {
+		if task.State != state {
+			return false
+		}
+	}
+	return true
 }
 
 type ConnectorState struct {
-	State    string
+	State    string `json:"state"`
 	WorkerId string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
 }
 
 func (c ConnectorState) FormattedState() string {
@@ -90,63 +257,85 @@ func (c ConnectorState) FormattedState() string {
 }
 
 // GetConnectorDetails gets all connector and task statuses and config for a given connectorName
-func GetConnectorDetails(host string, port string, connectorId int, connectorName string) ConnectorDetails {
+func GetConnectorDetails(client *connect.Client, connectorId int, connectorName string) (ConnectorDetails, error) {
 	log.Debug("getting connector details for ", connectorId, " ", connectorName)
 
-	cDetails := GetConnectorStatus(host, port, connectorName)
-	cDetails.Config = GetConnectorConfig(host, port, connectorName)
+	cDetails, err := GetConnectorStatus(client, connectorName)
+	if err != nil {
+		return cDetails, err
+	}
+
+	cDetails.Config, err = GetConnectorConfig(client, connectorName)
+	if err != nil {
+		return cDetails, err
+	}
 
-	tasksMap := GetConnectorTasks(host, port, connectorName)
+	tasksMap, err := GetConnectorTasks(client, connectorName)
+	if err != nil {
+		return cDetails, err
+	}
 
 	for j, connectorTaskStatus := range cDetails.Tasks {
 		cDetails.Tasks[j].Config = tasksMap[connectorTaskStatus.Id].Config
 	}
-	return cDetails
+	return cDetails, nil
 
 }
 
 // GetConnectorStatus gets the connector status
-func GetConnectorStatus(host string, port string, connectorName string) ConnectorDetails {
-	statusUrl := fmt.Sprintf("http://%s:%s/connectors/%s/status", host, port, connectorName)
-	log.Debug("getting connector status using URL: ", statusUrl)
-	resp, err := http.Get(statusUrl)
-	cobra.CheckErr(err)
+func GetConnectorStatus(client *connect.Client, connectorName string) (ConnectorDetails, error) {
+	status, err := client.ConnectorStatus(connectorName)
+	if err != nil {
+		return ConnectorDetails{}, err
+	}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	cobra.CheckErr(err)
+	return newConnectorDetails(status), nil
+}
 
-	var status ConnectorDetails
-	json.Unmarshal(bodyBytes, &status)
-	return status
+func newConnectorDetails(status connect.ConnectorStatus) ConnectorDetails {
+	details := ConnectorDetails{
+		Name:      status.Name,
+		Connector: ConnectorState(status.Connector),
+	}
+	for _, t := range status.Tasks {
+		details.Tasks = append(details.Tasks, TaskState{Id: t.Id, State: t.State, WorkerId: t.WorkerId, Trace: t.Trace})
+	}
+	return details
 }
 
 // GetConnectorConfig gets the connector config
-func GetConnectorConfig(host string, port string, connectorName string) map[string]string {
-	url := fmt.Sprintf("http://%s:%s/connectors/%s/config", host, port, connectorName)
-	log.Debug("getting connector config using URL: ", url)
-	resp, err := http.Get(url)
-	cobra.CheckErr(err)
-
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	cobra.CheckErr(err)
-
-	var config map[string]string
-	json.Unmarshal(bodyBytes, &config)
-	return config
+func GetConnectorConfig(client *connect.Client, connectorName string) (map[string]string, error) {
+	return client.ConnectorConfig(connectorName)
 }
 
-// Tasks
+var serverInfo *connect.ServerInfo
 
-type TaskStatus struct {
-	Id     TaskStatusId
-	Config map[string]string
+// GetServerInfo returns the version of the Kafka Connect worker, it is only requested once
+func GetServerInfo(client *connect.Client) (connect.ServerInfo, error) {
+	if serverInfo != nil {
+		return *serverInfo, nil
+	}
+	info, err := client.ServerInfo()
+	if err != nil {
+		return info, err
+	}
+	log.Debug("Kafka Connect version: ", info.Version)
+	serverInfo = &info
+	return info, nil
 }
 
-type TaskStatusId struct {
-	Connector string
-	TaskId    int `json:"task"`
+// SupportsBulkRestart reports whether the worker can restart a connector and its tasks in one request
+func SupportsBulkRestart(client *connect.Client) bool {
+	info, err := GetServerInfo(client)
+	if err != nil {
+		log.Debug("could not get the Kafka Connect version: ", err)
+		return false
+	}
+	return info.AtLeast(3, 0)
 }
 
+// Tasks
+
 type TaskStatusConfig struct {
 	Tables      string
 	Query       string
@@ -154,29 +343,19 @@ type TaskStatusConfig struct {
 	TopicsRegex string `json:"topics.regex"`
 }
 
-func GetConnectorTasks(host string, port string, connector string) map[int]TaskStatus {
-
-	statusUrl := fmt.Sprintf("http://%s:%s/connectors/%s/tasks", host, port, connector)
-	log.Debug("getting tasks using URL: ", statusUrl)
-
-	resp, err := http.Get(statusUrl)
-	cobra.CheckErr(err)
+func GetConnectorTasks(client *connect.Client, connector string) (map[int]connect.TaskInfo, error) {
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	cobra.CheckErr(err)
-
-	//fmt.Println(string(bodyBytes))
-
-	var tasks []TaskStatus
-	json.Unmarshal(bodyBytes, &tasks)
-	//fmt.Println(tasks)
+	tasks, err := client.ConnectorTasks(connector)
+	if err != nil {
+		return nil, err
+	}
 
-	tasksMap := make(map[int]TaskStatus)
+	tasksMap := make(map[int]connect.TaskInfo)
 
-	for _, taskStatus := range tasks {
-		tasksMap[taskStatus.Id.TaskId] = taskStatus
+	for _, taskInfo := range tasks {
+		tasksMap[taskInfo.Id.Task] = taskInfo
 	}
 
-	return tasksMap
+	return tasksMap, nil
 
 }
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jack-tee/conan/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConnect serves the status, config and tasks endpoints for each of the connectors
+// and ?expand when expand is set, requests counts the requests made to it
+func fakeConnect(t *testing.T, connectors []string, expand bool, requests *int32) *connect.Client {
+	server := httptest.NewServer(fakeConnectHandler(connectors, expand, requests))
+	t.Cleanup(server.Close)
+	return connect.NewClient(server.URL)
+}
+
+func fakeConnectHandler(connectors []string, expand bool, requests *int32) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/connectors", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if expand && len(r.URL.Query()["expand"]) > 0 {
+			var expanded []string
+			for _, name := range connectors {
+				expanded = append(expanded, fmt.Sprintf(`"%s":{"status":{"name":"%s","connector":{"state":"PAUSED","worker_id":"w1"},"tasks":[{"id":0,"state":"PAUSED","worker_id":"w1"}]},"info":{"name":"%s","config":{"name":"%s"},"tasks":[{"connector":"%s","task":0}]}}`, name, name, name, name, name))
+			}
+			fmt.Fprintf(w, "{%s}", strings.Join(expanded, ","))
+			return
+		}
+		fmt.Fprintf(w, `["%s"]`, strings.Join(connectors, `","`))
+	})
+	mux.HandleFunc("/connectors/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/connectors/"), "/")
+		name := parts[0]
+		switch parts[1] {
+		case "status":
+			fmt.Fprintf(w, `{"name":"%s","connector":{"state":"RUNNING","worker_id":"w1"},"tasks":[{"id":0,"state":"RUNNING","worker_id":"w1"}]}`, name)
+		case "config":
+			fmt.Fprintf(w, `{"name":"%s","poll.interval.ms":"5000"}`, name)
+		case "tasks":
+			fmt.Fprintf(w, `[{"id":{"connector":"%s","task":0},"config":{"tables":"%s-table"}}]`, name, name)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return mux
+}
+
+func Test_GetConnectorsDetailsConcurrently(t *testing.T) {
+	var names []string
+	for i := 0; i < 50; i++ {
+		names = append(names, fmt.Sprintf("connector-%02d", i))
+	}
+	var requests, inFlight, maxInFlight int32
+	handler := fakeConnectHandler(names, false, &requests)
+	// record the most requests in flight at once, the sleep gives the requests time to overlap
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := connect.NewClient(server.URL)
+
+	defer func(previous int) { concurrency = previous }(concurrency)
+	concurrency = 4
+	connectors, err := GetConnectorsMap(client)
+	assert.NoError(t, err)
+
+	connectors, err = GetConnectorsDetails(client, connectors)
+	assert.NoError(t, err)
+	assert.Len(t, connectors, 50)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(concurrency))
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1), "the connectors are requested concurrently")
+
+	for i, name := range names {
+		assert.Equal(t, i, connectors[i].Id)
+		assert.Equal(t, name, connectors[i].Name)
+		assert.Equal(t, name, connectors[i].Details.Config["name"])
+		assert.Equal(t, name+"-table", connectors[i].Details.Tasks[0].Config["tables"])
+	}
+}
+
+func Test_GetConnectorsStatusUsesExpand(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"a-connector", "b-connector"}, true, &requests)
+
+	connectors, err := GetConnectorsMap(client)
+	assert.NoError(t, err)
+
+	connectors, err = GetConnectorsStatus(client, connectors)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), requests)
+	assert.Equal(t, "PAUSED", connectors[1].Details.Connector.State)
+	assert.Equal(t, "b-connector", connectors[1].Details.Config["name"])
+}
+
+func Test_GetConnectorsStatusFallsBackWithoutExpand(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"a-connector", "b-connector"}, false, &requests)
+
+	connectors, err := GetConnectorsMap(client)
+	assert.NoError(t, err)
+
+	connectors, err = GetConnectorsStatus(client, connectors)
+	assert.NoError(t, err)
+	// the list, the failed expand and a status and config request per connector
+	assert.Equal(t, int32(6), requests)
+	assert.Equal(t, "RUNNING", connectors[0].Details.Connector.State)
+	assert.Equal(t, "a-connector", connectors[0].Details.Config["name"])
+}
+
+func Test_GetConnectorsWithStatusListsWithExpand(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"b-connector", "a-connector", "c-other"}, true, &requests)
+
+	connectors, err := GetConnectorsWithStatus(client, "connector")
+	assert.NoError(t, err)
+	// the expanded connectors are the list
+	assert.Equal(t, int32(1), requests)
+	assert.Len(t, connectors, 2)
+	assert.Equal(t, "a-connector", connectors[0].Name)
+	assert.Equal(t, "b-connector", connectors[1].Name)
+	assert.Equal(t, "PAUSED", connectors[1].Details.Connector.State)
+}
+
+func Test_GetConnectorsWithStatusFallsBackWithoutExpand(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"a-connector", "b-connector"}, false, &requests)
+
+	connectors, err := GetConnectorsWithStatus(client, "b-")
+	assert.NoError(t, err)
+	// the failed expand, the list and a status and config request for the filtered connector
+	assert.Equal(t, int32(4), requests)
+	assert.Len(t, connectors, 1)
+	assert.Equal(t, "RUNNING", connectors[1].Details.Connector.State)
+}
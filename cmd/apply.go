@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jack-tee/conan/connect"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var prune bool
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Make the deployed connectors match a directory of connector config files",
+	Long: `Make the deployed connectors match a directory of connector config files.
+
+Connectors in the files that are not deployed are created, connectors whose config differs are updated
+and with --prune deployed connectors that are not in the files are deleted.
+A file can set the desired state of its connector, RUNNING or PAUSED, alongside the config e.g
+{"name": "my-connector", "state": "PAUSED", "config": {...}}`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No args provided. Please provide paths to the configuration files to apply e.g > conan apply /conf/ /otherconf/*.json\n")
+			return
+		}
+
+		files := ReadConfigFiles(args)
+
+		if len(files) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No configuration files found for provided paths.\n")
+			return
+		}
+
+		applyConfigFiles(cmd, GetRetryingClient(cmd), files, false)
+	},
+}
+
+// applyConfigFiles validates the files, shows the changes needed to make the deployed connectors
+// match them and applies the changes after confirmation, with dryRun the changes are only shown
+func applyConfigFiles(cmd *cobra.Command, client *connect.Client, files []ConfigFile, dryRun bool) {
+	if !ValidateConfigFiles(client, files) {
+		err := templates.ExecuteTemplate(cmd.OutOrStdout(), "ValidationTemplate", files)
+		cobra.CheckErr(err)
+		fmt.Fprintf(cmd.OutOrStdout(), "Validation errors found, skipped applying configs.\n")
+		os.Exit(1)
+	}
+
+	plan, err := Plan(client, files, prune)
+	cobra.CheckErr(err)
+
+	err = templates.ExecuteTemplate(cmd.OutOrStdout(), "DiffTemplate", plan)
+	cobra.CheckErr(err)
+
+	if !plan.HasChanges() {
+		fmt.Fprintf(cmd.OutOrStdout(), "Nothing to apply.\n")
+		return
+	}
+
+	if dryRun {
+		fmt.Fprintf(cmd.OutOrStdout(), "Dry run, skipped applying changes.\n")
+		return
+	}
+
+	if !assumeYes {
+		if !IsInteractive() {
+			cobra.CheckErr(fmt.Errorf("stdin is not a terminal so cannot confirm, use --yes to apply the changes"))
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Apply the above changes? y/N ")
+		if !AwaitUserConfirm() {
+			fmt.Fprintf(cmd.OutOrStdout(), "Skipped applying changes.\n")
+			return
+		}
+	}
+
+	results := ApplyPlan(client, files, plan)
+	cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OperationResultTemplate", results))
+	if results.Failed() > 0 {
+		os.Exit(1)
+	}
+}
+
+// Plan compares the files to the deployed connectors and works out the changes needed
+// to make the deployed connectors match them, when prune is set the OmittedConnectors are to be deleted
+func Plan(client *connect.Client, files []ConfigFile, prune bool) (*DiffResults, error) {
+	plan, err := Diff(client, files, prune)
+	if err != nil {
+		return nil, err
+	}
+	plan.Prune = prune
+
+	for _, file := range files {
+		if file.State == "" {
+			continue
+		}
+		if file.State != "RUNNING" && file.State != "PAUSED" {
+			return nil, fmt.Errorf("the state of %s in %s is %s, expected RUNNING or PAUSED", file.ConnectorName, file.FileName, file.State)
+		}
+
+		if contains(plan.NewConnectors, file.ConnectorName) {
+			// new connectors start RUNNING
+			if file.State == "PAUSED" {
+				plan.StateChanges = append(plan.StateChanges, StateChange{ConnectorName: file.ConnectorName, To: file.State})
+			}
+			continue
+		}
+
+		status, err := client.ConnectorStatus(file.ConnectorName)
+		if err != nil {
+			return nil, err
+		}
+
+		existingState := status.Connector.State
+		if existingState == file.State {
+			continue
+		}
+		if existingState != "RUNNING" && existingState != "PAUSED" {
+			log.Warnf("cannot set the state of %s to %s as its existing state is %s", file.ConnectorName, file.State, existingState)
+			continue
+		}
+		plan.StateChanges = append(plan.StateChanges, StateChange{ConnectorName: file.ConnectorName, From: existingState, To: file.State})
+	}
+	return plan, nil
+}
+
+// ApplyPlan creates and updates the connectors, sets their state and deletes the pruned connectors
+func ApplyPlan(client *connect.Client, files []ConfigFile, plan *DiffResults) OperationResults {
+	filesByName := make(map[string]ConfigFile)
+	for _, file := range files {
+		filesByName[file.ConnectorName] = file
+	}
+
+	var results OperationResults
+	record := func(operation string, connectorName string, resp *connect.Response, err error) {
+		// the connectors in the plan are not listed so have no connectorId
+		result := OperationResult{ConnectorId: -1, ConnectorName: connectorName, TaskId: -1, Operation: operation}
+		result.complete(resp, err)
+		results = append(results, result)
+	}
+
+	for _, name := range plan.NewConnectors {
+		resp, err := client.PutConnectorConfig(name, filesByName[name].Config)
+		record("create", name, resp, err)
+	}
+
+	for _, changed := range plan.ChangedConnectors {
+		resp, err := client.PutConnectorConfig(changed.ConnectorName, filesByName[changed.ConnectorName].Config)
+		record("update", changed.ConnectorName, resp, err)
+	}
+
+	results = append(results, SetStates(client, StatePlan{Changes: plan.StateChanges})...)
+
+	if plan.Prune {
+		for _, name := range plan.OmittedConnectors {
+			resp, err := ExecuteConnectorOp(client, Delete, name)
+			record(Delete.Mode, name, resp, err)
+		}
+	}
+	return results
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().BoolVar(&prune, "prune", false, "delete deployed connectors that are not included in the specified config files")
+	applyCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not prompt for confirmation")
+	addConfigFileFlags(applyCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Plan(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"changed", "omitted", "unchanged"}, false, &requests)
+
+	files := []ConfigFile{
+		{ConnectorName: "changed", State: "PAUSED", Config: map[string]string{"name": "changed", "poll.interval.ms": "1000"}},
+		{ConnectorName: "unchanged", State: "RUNNING", Config: map[string]string{"name": "unchanged", "poll.interval.ms": "5000"}},
+		{ConnectorName: "new", State: "PAUSED", Config: map[string]string{"name": "new"}},
+	}
+
+	plan, err := Plan(client, files, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new"}, plan.NewConnectors)
+	assert.Equal(t, "changed", plan.ChangedConnectors[0].ConnectorName)
+	assert.Equal(t, []string{"unchanged"}, plan.UnchangedConnectors)
+	assert.Equal(t, []string{"omitted"}, plan.OmittedConnectors)
+	assert.Equal(t, []StateChange{
+		{ConnectorName: "changed", From: "RUNNING", To: "PAUSED"},
+		{ConnectorName: "new", To: "PAUSED"},
+	}, plan.StateChanges)
+	assert.True(t, plan.HasChanges())
+}
+
+func Test_PlanInvalidState(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"a"}, false, &requests)
+
+	_, err := Plan(client, []ConfigFile{{ConnectorName: "a", State: "STOPPED"}}, false)
+	assert.Error(t, err)
+}
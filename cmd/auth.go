@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jack-tee/conan/connect"
+	log "github.com/sirupsen/logrus"
+)
+
+// credentials are only read from env vars or files so they never appear in argv
+var caCert string
+var clientCert string
+var clientKey string
+var basicAuthFile string
+var tokenFile string
+
+// configureClient applies the TLS and auth flags to client
+func configureClient(client *connect.Client) error {
+	if caCert != "" || clientCert != "" || clientKey != "" {
+		tlsConfig, err := connect.NewTLSConfig(caCert, clientCert, clientKey)
+		if err != nil {
+			return err
+		}
+		client.HTTPClient = connect.NewHTTPClient(tlsConfig)
+	}
+
+	username, password, err := basicAuthCredentials()
+	if err != nil {
+		return err
+	}
+	token, err := bearerToken()
+	if err != nil {
+		return err
+	}
+	if token != "" && username != "" {
+		return errors.New("both basic auth credentials and a bearer token are set, only one can be used")
+	}
+
+	client.Username = username
+	client.Password = password
+	client.Token = token
+	return nil
+}
+
+// basicAuthCredentials returns the CONAN_USERNAME and CONAN_PASSWORD env vars
+// or the contents of the basic auth file in the form username:password
+func basicAuthCredentials() (string, string, error) {
+	if basicAuthFile == "" {
+		return os.Getenv("CONAN_USERNAME"), os.Getenv("CONAN_PASSWORD"), nil
+	}
+
+	log.Debug("reading basic auth credentials from ", basicAuthFile)
+	contents, err := ioutil.ReadFile(basicAuthFile)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read basic auth file: %w", err)
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(contents)), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("basic auth file %s should contain username:password", basicAuthFile)
+	}
+	return parts[0], parts[1], nil
+}
+
+// bearerToken returns the CONAN_TOKEN env var or the contents of the token file
+func bearerToken() (string, error) {
+	if tokenFile == "" {
+		return os.Getenv("CONAN_TOKEN"), nil
+	}
+
+	log.Debug("reading bearer token from ", tokenFile)
+	contents, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("could not read token file: %w", err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
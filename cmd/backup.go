@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/jack-tee/conan/connect"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// SnapshotVersion is the version of the snapshot format written by state backup
+const SnapshotVersion = 1
+
+var restoreDryRun bool
+
+// Snapshot is a backup of the config and state of the deployed connectors
+type Snapshot struct {
+	Version    int                 `json:"version"`
+	CreatedAt  time.Time           `json:"created_at"`
+	Connectors []SnapshotConnector `json:"connectors"`
+}
+
+type SnapshotConnector struct {
+	Name      string            `json:"name"`
+	State     string            `json:"state"`
+	TaskCount int               `json:"task_count"`
+	Config    map[string]string `json:"config"`
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [file]",
+	Short: "save the config and state of the connectors to a snapshot file",
+	Long: `save the config and state of the connectors to a json snapshot file which state restore can recreate them from.
+The snapshot contains the full config of each connector including any secrets.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		filename := fmt.Sprintf("./conan-backup-%s.json", time.Now().UTC().Format(time.RFC3339))
+		if len(args) > 0 {
+			filename = args[0]
+		}
+
+		snapshot, err := TakeSnapshot(GetClient(cmd))
+		cobra.CheckErr(err)
+
+		b, err := json.MarshalIndent(snapshot, "", "  ")
+		cobra.CheckErr(err)
+		// the snapshot contains secrets so is only readable by the owner
+		cobra.CheckErr(ioutil.WriteFile(filename, append(b, '\n'), 0600))
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Backed up %d Connectors to %s.\n", len(snapshot.Connectors), filename)
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "recreate connectors and set their config and state from a snapshot file",
+	Long: `recreate the connectors in a snapshot file taken by state backup that are missing, update the config of those
+that have changed and set their state back to RUNNING or PAUSED. Connectors that are not in the snapshot are left as they are.`,
+	Args:   cobra.ExactArgs(1),
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot, err := ReadSnapshot(args[0])
+		cobra.CheckErr(err)
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Restoring %d Connectors from the snapshot taken at %s.\n", len(snapshot.Connectors), snapshot.CreatedAt.Format(time.RFC3339))
+		applyConfigFiles(cmd, GetRetryingClient(cmd), snapshot.ConfigFiles(args[0]), restoreDryRun)
+	},
+}
+
+// TakeSnapshot gets the config and state of each of the deployed connectors
+func TakeSnapshot(client *connect.Client) (Snapshot, error) {
+	connectors, err := GetConnectorsWithStatus(client, "")
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{Version: SnapshotVersion, CreatedAt: time.Now().UTC(), Connectors: make([]SnapshotConnector, 0, len(connectors))}
+	for _, c := range SortedConnectors(connectors) {
+		snapshot.Connectors = append(snapshot.Connectors, SnapshotConnector{
+			Name:      c.Name,
+			State:     c.Details.Connector.State,
+			TaskCount: len(c.Details.Tasks),
+			Config:    c.Details.Config,
+		})
+	}
+	return snapshot, nil
+}
+
+// ReadSnapshot reads a snapshot file, snapshots written by newer versions of conan are rejected
+func ReadSnapshot(filename string) (Snapshot, error) {
+	var snapshot Snapshot
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("could not read snapshot %s: %w", filename, err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return snapshot, fmt.Errorf("snapshot %s has version %d, this version of conan can restore snapshots up to version %d", filename, snapshot.Version, SnapshotVersion)
+	}
+	return snapshot, nil
+}
+
+// ConfigFiles returns the connectors in the snapshot as config files so they can be applied,
+// only the RUNNING and PAUSED states are restored
+func (s Snapshot) ConfigFiles(filename string) []ConfigFile {
+	files := make([]ConfigFile, 0, len(s.Connectors))
+	for i, c := range s.Connectors {
+		file := ConfigFile{FileName: fmt.Sprintf("%s[%d]", filename, i), ConnectorName: c.Name, Config: c.Config}
+		if c.State == "RUNNING" || c.State == "PAUSED" {
+			file.State = c.State
+		} else {
+			log.Debugf("not restoring the %s state of connector %s", c.State, c.Name)
+		}
+		file.setPluginClass()
+		files = append(files, file)
+	}
+	return files
+}
+
+func init() {
+	stateCmd.AddCommand(backupCmd)
+	stateCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "only show the changes that would be made")
+	restoreCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not prompt for confirmation")
+}
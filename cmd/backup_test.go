@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TakeSnapshot(t *testing.T) {
+	var requests int32
+	client := fakeConnect(t, []string{"b", "a"}, false, &requests)
+
+	snapshot, err := TakeSnapshot(client)
+	assert.NoError(t, err)
+	assert.Equal(t, SnapshotVersion, snapshot.Version)
+	assert.Equal(t, []SnapshotConnector{
+		{Name: "a", State: "RUNNING", TaskCount: 1, Config: map[string]string{"name": "a", "poll.interval.ms": "5000"}},
+		{Name: "b", State: "RUNNING", TaskCount: 1, Config: map[string]string{"name": "b", "poll.interval.ms": "5000"}},
+	}, snapshot.Connectors)
+}
+
+func Test_ReadSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := Snapshot{Version: SnapshotVersion, Connectors: []SnapshotConnector{
+		{Name: "a", State: "PAUSED", TaskCount: 2, Config: map[string]string{"connector.class": "io.Sink"}},
+		{Name: "b", State: "FAILED", TaskCount: 1, Config: map[string]string{"connector.class": "io.Source"}},
+	}}
+	b, _ := json.Marshal(snapshot)
+	filename := filepath.Join(dir, "backup.json")
+	assert.NoError(t, ioutil.WriteFile(filename, b, 0600))
+
+	read, err := ReadSnapshot(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Connectors, read.Connectors)
+
+	files := read.ConfigFiles(filename)
+	assert.Equal(t, "a", files[0].ConnectorName)
+	assert.Equal(t, "PAUSED", files[0].State)
+	assert.Equal(t, "Sink", files[0].PluginClass)
+	// only RUNNING and PAUSED states are restored
+	assert.Equal(t, "", files[1].State)
+}
+
+func Test_ReadSnapshotNewerVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "backup.json")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"version": 99, "connectors": []}`), 0600))
+
+	_, err := ReadSnapshot(filename)
+	assert.Error(t, err)
+}
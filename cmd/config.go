@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cfgFile string
+var cluster string
+
+// Cluster is a named Kafka Connect cluster defined in the config file e.g
+//
+//	cluster: dev
+//	clusters:
+//	  dev:
+//	    host: localhost
+//	  prod:
+//	    host: connect.prod.example.com
+//	    port: 443
+//	    scheme: https
+//	    caCert: $HOME/certs/ca.pem
+//	    basicAuthFile: $HOME/.conan-prod-credentials
+//	    templatesPath: $HOME/conan/templates/*.tmpl
+type Cluster struct {
+	Host          string
+	Port          string
+	Scheme        string
+	CACert        string `mapstructure:"caCert"`
+	ClientCert    string `mapstructure:"clientCert"`
+	ClientKey     string `mapstructure:"clientKey"`
+	BasicAuthFile string `mapstructure:"basicAuthFile"`
+	TokenFile     string `mapstructure:"tokenFile"`
+	TemplatesPath string `mapstructure:"templatesPath"`
+}
+
+// initConfig reads in the config file and applies the selected cluster
+// to any of the persistent flags that were not set on the command line
+func initConfig(cmd *cobra.Command, args []string) {
+	cobra.CheckErr(loadConfig())
+}
+
+// loadConfig reads the config file and sets the persistent flags from the selected cluster
+func loadConfig() error {
+	if cfgFile != "" {
+		// Use config file from the flag.
+		viper.SetConfigFile(cfgFile)
+	} else {
+		// Find home directory.
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+
+		// Search config in home directory with name ".conan" (without extension).
+		viper.AddConfigPath(home)
+		viper.SetConfigType("yaml")
+		viper.SetConfigName(".conan")
+	}
+
+	err := viper.ReadInConfig()
+	if _, notFound := err.(viper.ConfigFileNotFoundError); err != nil && !(notFound && cfgFile == "") {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	name := selectedCluster()
+	if name == "" {
+		return nil
+	}
+
+	clusters := make(map[string]Cluster)
+	if err := viper.UnmarshalKey("clusters", &clusters); err != nil {
+		return err
+	}
+
+	// viper lower cases all keys
+	c, ok := clusters[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("cluster [%s] is not defined in the config file %s, defined clusters are [%s]", name, viper.ConfigFileUsed(), strings.Join(clusterNames(clusters), ", "))
+	}
+
+	flags := rootCmd.PersistentFlags()
+	profile := map[string]string{
+		"host":            c.Host,
+		"port":            c.Port,
+		"scheme":          c.Scheme,
+		"ca-cert":         c.CACert,
+		"client-cert":     c.ClientCert,
+		"client-key":      c.ClientKey,
+		"basic-auth-file": c.BasicAuthFile,
+		"token-file":      c.TokenFile,
+		"templatesPath":   c.TemplatesPath,
+	}
+	for flag, val := range profile {
+		if val != "" && !flags.Changed(flag) {
+			if err := flags.Set(flag, os.ExpandEnv(val)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectedCluster returns the cluster set by the --cluster flag, the CONAN_CLUSTER env var
+// or the cluster key in the config file, in that order
+func selectedCluster() string {
+	if cluster != "" {
+		return cluster
+	}
+	if env := os.Getenv("CONAN_CLUSTER"); env != "" {
+		return env
+	}
+	return viper.GetString("cluster")
+}
+
+func clusterNames(clusters map[string]Cluster) []string {
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
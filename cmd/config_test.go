@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+cluster: dev
+clusters:
+  dev:
+    host: dev.example.com
+  prod:
+    host: prod.example.com
+    port: 443
+    scheme: https
+`
+
+// resetConfig resets viper and the persistent flags, and with them cfgFile and cluster,
+// before and after each test
+func resetConfig(t *testing.T) {
+	reset := func() {
+		viper.Reset()
+		rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+			if err := f.Value.Set(f.DefValue); err != nil {
+				t.Fatal(err)
+			}
+			f.Changed = false
+		})
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// setEnv sets the environment variable for the test, an empty value unsets it
+func setEnv(t *testing.T, key string, value string) {
+	previous, ok := os.LookupEnv(key)
+	if value == "" {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func Test_LoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		flags   map[string]string
+		env     string
+		host    string
+		port    string
+		scheme  string
+		wantErr string
+	}{
+		{name: "default cluster from the config file", config: testConfig, host: "dev.example.com", port: "8083", scheme: "http"},
+		{name: "cluster from env", config: testConfig, env: "prod", host: "prod.example.com", port: "443", scheme: "https"},
+		{name: "cluster flag over env", config: testConfig, env: "prod", flags: map[string]string{"cluster": "dev"}, host: "dev.example.com", port: "8083", scheme: "http"},
+		{name: "cluster names are case insensitive", config: testConfig, flags: map[string]string{"cluster": "PROD"}, host: "prod.example.com", port: "443", scheme: "https"},
+		{name: "flags over the profile", config: testConfig, flags: map[string]string{"cluster": "prod", "host": "other.example.com"}, host: "other.example.com", port: "443", scheme: "https"},
+		{name: "no cluster selected", config: "clusters:\n  dev:\n    host: dev.example.com\n", host: "localhost", port: "8083", scheme: "http"},
+		{name: "unknown cluster", config: testConfig, flags: map[string]string{"cluster": "staging"}, wantErr: "cluster [staging] is not defined in the config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetConfig(t)
+			setEnv(t, "CONAN_CLUSTER", tt.env)
+			path := writeConfigFile(t, t.TempDir(), "conan.yaml", tt.config)
+
+			flags := rootCmd.PersistentFlags()
+			assert.NoError(t, flags.Set("config", path))
+			for flag, val := range tt.flags {
+				assert.NoError(t, flags.Set(flag, val))
+			}
+
+			err := loadConfig()
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+					assert.Contains(t, err.Error(), "defined clusters are [dev, prod]")
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.host, host)
+			assert.Equal(t, tt.port, port)
+			assert.Equal(t, tt.scheme, scheme)
+		})
+	}
+}
+
+func Test_LoadConfigMissingFile(t *testing.T) {
+	resetConfig(t)
+	setEnv(t, "CONAN_CLUSTER", "")
+
+	// a missing --config file is an error
+	cfgFile = filepath.Join(t.TempDir(), "missing.yaml")
+	err := loadConfig()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "could not read config file")
+	}
+
+	// but there doesn't have to be a $HOME/.conan.yaml
+	resetConfig(t)
+	setEnv(t, "HOME", t.TempDir())
+	assert.NoError(t, loadConfig())
+	assert.Equal(t, "localhost", host)
+
+	// unless a cluster is selected
+	setEnv(t, "CONAN_CLUSTER", "prod")
+	assert.Error(t, loadConfig())
+}
@@ -17,173 +17,261 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
+	"os"
 	"strings"
 
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var showOmitted bool
+var exitCode bool
 
 type DiffResults struct {
-	DiffedConnectors    []string
-	NewConnectors       []string
-	ChangedConnectors   []DiffResult
-	UnchangedConnectors []string
-	OmittedConnectors   []string
-	ShowOmitted         bool
+	DiffedConnectors    []string      `json:"diffed_connectors"`
+	NewConnectors       []string      `json:"new_connectors"`
+	ChangedConnectors   []DiffResult  `json:"changed_connectors"`
+	UnchangedConnectors []string      `json:"unchanged_connectors"`
+	OmittedConnectors   []string      `json:"omitted_connectors,omitempty"`
+	StateChanges        []StateChange `json:"state_changes,omitempty"`
+	ShowOmitted         bool          `json:"-"`
+	Prune               bool          `json:"prune,omitempty"`
+}
+
+// StateChange is a change to the state of a connector that apply will make,
+// From is empty for connectors that will be created
+type StateChange struct {
+	ConnectorName string `json:"connector_name"`
+	From          string `json:"from"`
+	To            string `json:"to"`
 }
 
 type DiffResult struct {
-	ConnectorName string
-	NewKeys       map[string]string
-	MatchKeys     map[string]string
-	MismatchKeys  map[string]MismatchVals
-	RemovedKeys   map[string]string
+	ConnectorName string                  `json:"connector_name"`
+	NewKeys       map[string]string       `json:"new_keys"`
+	MatchKeys     map[string]string       `json:"match_keys"`
+	MismatchKeys  map[string]MismatchVals `json:"mismatch_keys"`
+	RemovedKeys   map[string]string       `json:"removed_keys"`
 }
 
 type MismatchVals struct {
-	Deployed string
-	File     string
+	Deployed string `json:"deployed"`
+	File     string `json:"file"`
 }
 
+// exit codes used by diff when --exit-code is set
+const (
+	DiffExitNoChanges = 0
+	DiffExitChanges   = 1
+	DiffExitError     = 2
+)
+
 // diffCmd represents the diff command
 var diffCmd = &cobra.Command{
-	Use:    "diff",
-	Short:  "Compare connector config files with what is currently deployed",
-	Long:   `Compare connector config files with what is currently deployed`,
+	Use:   "diff",
+	Short: "Compare connector config files with what is currently deployed",
+	Long:  `Compare connector config files with what is currently deployed`,
+	// config errors are checked here rather than by the root command so --exit-code can exit with DiffExitError
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		checkDiffErr(loadConfig())
+	},
 	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
-			fmt.Fprintf(cmd.OutOrStdout(), "No args provided. Please provide paths to the configuration files to load e.g > conan diff /conf/conf.json /otherconf/*.json\n")
-			return
+			checkDiffErr(fmt.Errorf("no args provided, please provide paths to the configuration files to compare e.g > conan diff /conf/conf.json /otherconf/*.json"))
 		}
 
-		files := make([]ConfigFile, 0)
+		files, err := LoadConfigFiles(args)
+		checkDiffErr(err)
+
+		if len(files) == 0 {
+			checkDiffErr(fmt.Errorf("no configuration files found for the provided paths"))
+		}
 
-		for _, path := range args {
-			matches, err := filepath.Glob(path)
+		client, err := NewClient(cmd)
+		checkDiffErr(err)
 
-			cobra.CheckErr(err)
+		diffResults, err := Diff(client, files, showOmitted)
+		if diffResults == nil {
+			checkDiffErr(err)
+		}
 
-			if matches == nil {
-				log.Warn("no files found for arg ", path)
-			} else {
-				log.Debug("for arg ", path, " found files ", matches)
-				for _, file := range matches {
-					configFile := ConfigFile{FileName: file}
-					configFile.Read()
-					files = append(files, configFile)
-				}
-			}
+		if renderErr := render(cmd.OutOrStdout(), "DiffTemplate", diffResults); renderErr != nil {
+			checkDiffErr(fmt.Errorf("could not render the DiffTemplate: %w", renderErr))
 		}
 
-		if len(files) == 0 {
-			fmt.Fprintf(cmd.OutOrStdout(), "No configuration files found for provided paths.\n")
-			return
+		// some connectors could not be compared, these have already been logged as warnings
+		exitOnDiffError(err)
+
+		if exitCode && diffResults.HasChanges() {
+			os.Exit(DiffExitChanges)
 		}
+	},
+}
 
-		var diffResults DiffResults
-		diffResults.ShowOmitted = showOmitted
+// checkDiffErr exits if there is an error, with DiffExitError when --exit-code is set
+// so that errors cannot be mistaken for changes
+func checkDiffErr(err error) {
+	exitOnDiffError(err)
+	cobra.CheckErr(err)
+}
 
-		connectors := GetConnectorsList(host, port)
+// exitOnDiffError exits with DiffExitError if there is an error and --exit-code is set
+func exitOnDiffError(err error) {
+	if err != nil && exitCode {
+		log.Error(err)
+		os.Exit(DiffExitError)
+	}
+}
 
-		// loop through connectors and compare their config to what is deployed
-		for _, file := range files {
-			log.Debug("loading " + file.ConnectorName)
+// HasChanges reports whether loading the files would create or change connectors or their state,
+// or with --show-omitted whether there are deployed connectors not in the files
+func (d *DiffResults) HasChanges() bool {
+	return len(d.NewConnectors) > 0 || len(d.ChangedConnectors) > 0 || len(d.StateChanges) > 0 || (d.ShowOmitted && len(d.OmittedConnectors) > 0)
+}
 
-			diffResults.DiffedConnectors = append(diffResults.DiffedConnectors, file.ConnectorName)
+// Diff compares the config files with the deployed connectors.
+// If the deployed connectors cannot be listed the DiffResults are nil, if only some
+// connectors could not be compared they are left out of the results and an error is returned.
+func Diff(client *connect.Client, files []ConfigFile, showOmitted bool) (*DiffResults, error) {
+	var diffResults DiffResults
+	diffResults.ShowOmitted = showOmitted
 
-			if !contains(connectors, file.ConnectorName) {
-				log.Debug("the connector " + file.ConnectorName + " doesn't exist")
-				diffResults.NewConnectors = append(diffResults.NewConnectors, file.ConnectorName)
-				continue
-			}
+	connectors, err := GetConnectorsList(client)
+	if err != nil {
+		return nil, err
+	}
 
-			// get the currently deployed config for the connector
-			conf := GetConnectorConfig(host, port, file.ConnectorName)
-			log.Debug(conf)
-			if _, exists := conf["error_code"]; exists {
-				if message, exists := conf["message"]; exists {
-					log.Warn("there was an error getting the deployed connector config for " + file.ConnectorName + " - " + message)
-				} else {
-					log.Warn("there was an error getting the deployed connector config for " + file.ConnectorName)
-				}
-				continue
-			}
+	var failed []string
 
-			newKeys := make(map[string]string)
-			matchKeys := make(map[string]string)
-			mismatchKeys := make(map[string]MismatchVals)
-			removedKeys := make(map[string]string)
+	// loop through connectors and compare their config to what is deployed
+	for _, file := range files {
+		if file.Error != nil {
+			log.Warn("could not read " + file.FileName + " - " + file.Error.Error())
+			failed = append(failed, file.FileName)
+			continue
+		}
 
-			for fileKey, fileVal := range file.Config {
+		log.Debug("loading " + file.ConnectorName)
 
-				if deployedVal, exists := conf[fileKey]; exists {
-					if fileVal == deployedVal {
-						matchKeys[fileKey] = cleanseVal(fileKey, fileVal)
-					} else {
-						mismatchKeys[fileKey] = MismatchVals{Deployed: cleanseVal(fileKey, deployedVal), File: cleanseVal(fileKey, fileVal)}
-					}
+		diffResults.DiffedConnectors = append(diffResults.DiffedConnectors, file.ConnectorName)
 
-				} else {
-					newKeys[fileKey] = cleanseVal(fileKey, fileVal)
-				}
-			}
+		if !contains(connectors, file.ConnectorName) {
+			log.Debug("the connector " + file.ConnectorName + " doesn't exist")
+			diffResults.NewConnectors = append(diffResults.NewConnectors, file.ConnectorName)
+			continue
+		}
 
-			for deployedKey, deployedVal := range conf {
-				if _, exists := file.Config[deployedKey]; !exists {
-					removedKeys[deployedKey] = cleanseVal(deployedKey, deployedVal)
-				}
-			}
-			result := DiffResult{
-				file.ConnectorName,
-				newKeys,
-				matchKeys,
-				mismatchKeys,
-				removedKeys,
-			}
-			if len(result.NewKeys) == 0 && len(result.MismatchKeys) == 0 && len(result.RemovedKeys) == 0 {
-				// the connector is unchanged
-				diffResults.UnchangedConnectors = append(diffResults.UnchangedConnectors, result.ConnectorName)
-			} else {
-				diffResults.ChangedConnectors = append(diffResults.ChangedConnectors, result)
+		// get the currently deployed config for the connector
+		conf, err := GetConnectorConfig(client, file.ConnectorName)
+		if err != nil {
+			log.Warn("there was an error getting the deployed connector config for " + file.ConnectorName + " - " + err.Error())
+			failed = append(failed, file.ConnectorName)
+			continue
+		}
+
+		result := DiffConfig(file.ConnectorName, conf, file.Config, file.InterpolatedKeys)
+		if len(result.NewKeys) == 0 && len(result.MismatchKeys) == 0 && len(result.RemovedKeys) == 0 {
+			// the connector is unchanged
+			diffResults.UnchangedConnectors = append(diffResults.UnchangedConnectors, result.ConnectorName)
+		} else {
+			diffResults.ChangedConnectors = append(diffResults.ChangedConnectors, result)
+		}
+	}
+	log.Debug(diffResults)
+
+	if showOmitted {
+		for _, c := range connectors {
+			if !contains(diffResults.DiffedConnectors, c) {
+				diffResults.OmittedConnectors = append(diffResults.OmittedConnectors, c)
 			}
 		}
-		log.Debug(diffResults)
 
-		if showOmitted {
-			for _, c := range connectors {
-				if !contains(diffResults.DiffedConnectors, c) {
-					diffResults.OmittedConnectors = append(diffResults.OmittedConnectors, c)
-				}
+	}
+
+	if len(failed) > 0 {
+		return &diffResults, fmt.Errorf("could not compare [%s]", strings.Join(failed, ", "))
+	}
+	return &diffResults, nil
+}
+
+// DiffConfig compares the deployed config of a connector with the config from its file,
+// the values of the hiddenKeys and secret keys are hidden
+func DiffConfig(connectorName string, deployed map[string]string, file map[string]string, hiddenKeys map[string]bool) DiffResult {
+	newKeys := make(map[string]string)
+	matchKeys := make(map[string]string)
+	mismatchKeys := make(map[string]MismatchVals)
+	removedKeys := make(map[string]string)
+
+	for fileKey, fileVal := range file {
+
+		if deployedVal, exists := deployed[fileKey]; exists {
+			if fileVal == deployedVal {
+				matchKeys[fileKey] = cleanseVal(fileKey, fileVal, hiddenKeys)
+			} else {
+				mismatchKeys[fileKey] = MismatchVals{Deployed: cleanseVal(fileKey, deployedVal, hiddenKeys), File: cleanseVal(fileKey, fileVal, hiddenKeys)}
 			}
 
+		} else {
+			newKeys[fileKey] = cleanseVal(fileKey, fileVal, hiddenKeys)
 		}
+	}
 
-		err := templates.ExecuteTemplate(cmd.OutOrStdout(), "DiffTemplate", diffResults)
-		if err != nil {
-			fmt.Fprintf(cmd.OutOrStdout(), "Error rendering DiffTemplate template %e.\n", err)
-			return
+	for deployedKey, deployedVal := range deployed {
+		if _, exists := file[deployedKey]; !exists {
+			removedKeys[deployedKey] = cleanseVal(deployedKey, deployedVal, hiddenKeys)
 		}
-	},
+	}
+	return DiffResult{
+		connectorName,
+		newKeys,
+		matchKeys,
+		mismatchKeys,
+		removedKeys,
+	}
 }
 
 var keysToHide = []string{"connection.pass", "connection.user", "connection.url", "password"}
 
-func cleanseVal(key string, val string) string {
+// cleanseVal hides the values of keys that look like secrets or are one of the connector's hiddenKeys,
+// e.g. those that were resolved from placeholders
+func cleanseVal(key string, val string, hiddenKeys map[string]bool) string {
+	if hiddenKeys[key] || isSecretKey(key) {
+		return "***hidden***"
+	}
+	return val
+}
+
+// cleanseConfig returns a copy of the config with the values of secret keys and the hiddenKeys hidden
+func cleanseConfig(config map[string]string, hiddenKeys map[string]bool) map[string]string {
+	if config == nil {
+		return nil
+	}
+	cleansed := make(map[string]string, len(config))
+	for k, v := range config {
+		cleansed[k] = cleanseVal(k, v, hiddenKeys)
+	}
+	return cleansed
+}
+
+// isSecretKey reports whether the config key looks like it holds a secret
+func isSecretKey(key string) bool {
 	for _, substr := range keysToHide {
 		if strings.Contains(key, substr) {
-			return "***hidden***"
+			return true
 		}
 	}
-	return val
+	return false
 }
 func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	diffCmd.Flags().BoolVarP(&showOmitted, "show-omitted", "o", false, "whether to show connectors that are currently deployed but are not included in the specified config files")
+	diffCmd.Flags().BoolVar(&exitCode, "exit-code", false, "exit with 1 if there are changes, 0 if there are none and 2 if there was an error")
+	// -o is already used by --show-omitted
+	diffCmd.Flags().StringVar(&outputFormat, "output", "text", "the output format, one of text, json or yaml")
+	addConfigFileFlags(diffCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffConfig(t *testing.T) {
+	deployed := map[string]string{"tables": "a", "poll.interval.ms": "1000", "removed": "x", "connection.password": "old"}
+	file := map[string]string{"tables": "a", "poll.interval.ms": "5000", "added": "y", "connection.password": "new"}
+
+	res := DiffConfig("my-connector", deployed, file, nil)
+	assert.Equal(t, map[string]string{"tables": "a"}, res.MatchKeys)
+	assert.Equal(t, map[string]string{"added": "y"}, res.NewKeys)
+	assert.Equal(t, map[string]string{"removed": "x"}, res.RemovedKeys)
+	assert.Equal(t, MismatchVals{Deployed: "1000", File: "5000"}, res.MismatchKeys["poll.interval.ms"])
+	assert.Equal(t, MismatchVals{Deployed: "***hidden***", File: "***hidden***"}, res.MismatchKeys["connection.password"])
+}
+
+func Test_DiffResultsHasChanges(t *testing.T) {
+	assert.False(t, (&DiffResults{UnchangedConnectors: []string{"a"}}).HasChanges())
+	assert.True(t, (&DiffResults{NewConnectors: []string{"a"}}).HasChanges())
+	assert.True(t, (&DiffResults{ChangedConnectors: []DiffResult{{ConnectorName: "a"}}}).HasChanges())
+	assert.False(t, (&DiffResults{OmittedConnectors: []string{"a"}}).HasChanges())
+	assert.True(t, (&DiffResults{OmittedConnectors: []string{"a"}, ShowOmitted: true}).HasChanges())
+}
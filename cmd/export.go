@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jack-tee/conan/connect"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportDir     string = ""
+	stripDefaults bool   = false
+	maskSecrets   bool   = false
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [filter]",
+	Short: "Write the deployed connectors to connector config files",
+	Long: `Write the config of the deployed connectors whose name contains the filter to {name}.json files in --dir,
+which can then be used with load, diff and apply.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := GetClient(cmd)
+		connectors, err := FilterConnectors(client, args)
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(os.MkdirAll(exportDir, 0755))
+
+		exported := exportConnectors(cmd.OutOrStdout(), client, connectors, exportDir)
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported %d Connectors.\n", exported)
+		if failed := len(connectors) - exported; failed > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Failed to export %d Connectors.\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+// exportConnectors writes each connector to a file in dir and returns how many were written
+func exportConnectors(w io.Writer, client *connect.Client, connectors map[int]Connector, dir string) int {
+	exported := 0
+	for _, connector := range SortedConnectors(connectors) {
+		definition, err := ExportConnector(client, connector)
+		if err == nil {
+			fileName := filepath.Join(dir, exportFileName(connector.Name))
+			if err = writeConnectorDefinition(fileName, definition); err == nil {
+				fmt.Fprintf(w, "Connector %d %s exported to %s.\n", connector.Id, connector.Name, fileName)
+				exported++
+				continue
+			}
+		}
+		fmt.Fprintf(w, "ERROR. Could not export connector %d %s: %s\n", connector.Id, connector.Name, err)
+	}
+	return exported
+}
+
+// ExportConnector returns the deployed config of the connector, without the keys set to the plugin's
+// default value with --strip-defaults and with secrets replaced by ${env:...} placeholders with --mask-secrets
+func ExportConnector(client *connect.Client, connector Connector) (ConnectorDefinition, error) {
+	config := make(map[string]string)
+	for k, v := range connector.Details.Config {
+		config[k] = v
+	}
+
+	if stripDefaults {
+		defaults, err := pluginDefaults(client, config)
+		if err != nil {
+			return ConnectorDefinition{}, err
+		}
+		for k, v := range config {
+			if defaultValue, ok := defaults[k]; ok && defaultValue == v && k != "connector.class" && k != "name" {
+				log.Debugf("stripping %s from %s as it is the default value", k, connector.Name)
+				delete(config, k)
+			}
+		}
+	}
+
+	if maskSecrets {
+		for k := range config {
+			if isSecretKey(k) {
+				config[k] = fmt.Sprintf("${env:%s}", secretEnvVar(connector.Name, k))
+			}
+		}
+	}
+	return ConnectorDefinition{Name: connector.Name, Config: config}, nil
+}
+
+// pluginDefaults returns the default value of each config key of the connector's plugin that has one
+func pluginDefaults(client *connect.Client, config map[string]string) (map[string]string, error) {
+	classParts := strings.Split(config["connector.class"], ".")
+	validation, err := client.ValidateConfig(classParts[len(classParts)-1], config)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the plugin defaults: %w", err)
+	}
+
+	defaults := make(map[string]string)
+	for _, field := range validation.Configs {
+		if field.Definition.DefaultValue != nil {
+			defaults[field.Definition.Name] = *field.Definition.DefaultValue
+		}
+	}
+	return defaults, nil
+}
+
+var nonEnvVarChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// secretEnvVar is the name of the environment variable a masked secret is read from
+// e.g. db1-connector and connection.password -> DB1_CONNECTOR_CONNECTION_PASSWORD
+func secretEnvVar(connectorName string, key string) string {
+	return strings.Trim(nonEnvVarChars.ReplaceAllString(strings.ToUpper(connectorName+"_"+key), "_"), "_")
+}
+
+// exportFileName is the name of the file a connector is exported to
+func exportFileName(connectorName string) string {
+	return safeFileName(connectorName) + ".json"
+}
+
+// safeFileName replaces the characters of a connector name that are not allowed in file names
+func safeFileName(connectorName string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(connectorName)
+}
+
+func writeConnectorDefinition(fileName string, definition ConnectorDefinition) error {
+	b, err := json.MarshalIndent(definition, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, append(b, '\n'), 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVar(&exportDir, "dir", "", "the directory to write the connector config files to")
+	exportCmd.Flags().StringVarP(&taskFilter, "task-filter", "t", "", "a substring to filter task summaries by")
+	exportCmd.Flags().StringVarP(&stateFilter, "state-filter", "s", "", "filter to connectors / tasks in this state")
+	exportCmd.Flags().BoolVar(&stripDefaults, "strip-defaults", false, "leave out config keys that are set to the plugin's default value")
+	exportCmd.Flags().BoolVar(&maskSecrets, "mask-secrets", false, "replace secret config values with ${env:CONNECTOR_KEY} placeholders")
+	cobra.CheckErr(exportCmd.MarkFlagRequired("dir"))
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jack-tee/conan/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExportConnector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/connector-plugins/JdbcSourceConnector/config/validate", r.URL.Path)
+		fmt.Fprint(w, `{"name":"JdbcSourceConnector","error_count":0,"configs":[
+			{"definition":{"name":"poll.interval.ms","type":"INT","default_value":"5000"},"value":{"name":"poll.interval.ms","errors":[]}},
+			{"definition":{"name":"batch.max.rows","type":"INT","default_value":"100"},"value":{"name":"batch.max.rows","errors":[]}},
+			{"definition":{"name":"connection.password","type":"PASSWORD","default_value":null},"value":{"name":"connection.password","errors":[]}}
+		]}`)
+	}))
+	defer server.Close()
+
+	connector := Connector{Id: 0, Name: "db1-table1", Details: ConnectorDetails{Config: map[string]string{
+		"name":                "db1-table1",
+		"connector.class":     "io.confluent.connect.jdbc.JdbcSourceConnector",
+		"poll.interval.ms":    "5000",
+		"batch.max.rows":      "500",
+		"connection.password": "s3cret",
+	}}}
+
+	stripDefaults, maskSecrets = true, true
+	defer func() { stripDefaults, maskSecrets = false, false }()
+
+	definition, err := ExportConnector(connect.NewClient(server.URL), connector)
+	assert.NoError(t, err)
+	assert.Equal(t, "db1-table1", definition.Name)
+	assert.Equal(t, map[string]string{
+		"name":                "db1-table1",
+		"connector.class":     "io.confluent.connect.jdbc.JdbcSourceConnector",
+		"batch.max.rows":      "500",
+		"connection.password": "${env:DB1_TABLE1_CONNECTION_PASSWORD}",
+	}, definition.Config)
+	// the deployed config is unchanged
+	assert.Equal(t, "s3cret", connector.Details.Config["connection.password"])
+}
+
+func Test_ExportedFileCanBeRead(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), exportFileName("a/b"))
+	assert.Equal(t, "a_b.json", filepath.Base(fileName))
+
+	definition := ConnectorDefinition{Name: "a/b", Config: map[string]string{"connector.class": "io.Sink", "tasks.max": "1"}}
+	assert.NoError(t, writeConnectorDefinition(fileName, definition))
+
+	files := ReadConfigFile(fileName)
+	assert.NoError(t, files[0].Error)
+	assert.Equal(t, "a/b", files[0].ConnectorName)
+	assert.Equal(t, definition.Config, files[0].Config)
+}
+
+func Test_ExportConnectorsCountsWrittenFiles(t *testing.T) {
+	dir := t.TempDir()
+	// a directory with the name of the file b is exported to makes writing it fail
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, exportFileName("b")), 0755))
+
+	connectors := map[int]Connector{
+		0: {Id: 0, Name: "a", Details: ConnectorDetails{Config: map[string]string{"connector.class": "io.Sink"}}},
+		1: {Id: 1, Name: "b", Details: ConnectorDetails{Config: map[string]string{"connector.class": "io.Sink"}}},
+	}
+
+	var out bytes.Buffer
+	assert.Equal(t, 1, exportConnectors(&out, nil, connectors, dir))
+	assert.Contains(t, out.String(), "Connector 0 a exported to")
+	assert.Contains(t, out.String(), "ERROR. Could not export connector 1 b")
+}
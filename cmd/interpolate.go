@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var failUnresolved bool
+
+// placeholder matches ${env:VAR} and ${file:/path/to/file.properties:key}
+var placeholder = regexp.MustCompile(`\$\{(env|file):([^}]*)\}`)
+
+// propertiesFiles caches the properties files read to resolve ${file:...} placeholders
+var propertiesFiles = make(map[string]map[string]string)
+
+// interpolate resolves the placeholders in the config values and returns the keys that had placeholders,
+// whose values are hidden in the same way as the keysToHide, and the placeholders that could not be resolved.
+// Unresolved placeholders are left in the value as the worker may have a config provider that resolves them
+func interpolate(config map[string]string) (map[string]bool, []string) {
+	interpolatedKeys := make(map[string]bool)
+	var unresolved []string
+	for key, val := range config {
+		if !placeholder.MatchString(val) {
+			continue
+		}
+		interpolatedKeys[key] = true
+
+		config[key] = placeholder.ReplaceAllStringFunc(val, func(match string) string {
+			parts := placeholder.FindStringSubmatch(match)
+			resolved, ok := resolvePlaceholder(parts[1], parts[2])
+			if !ok {
+				unresolved = append(unresolved, match)
+				return match
+			}
+			return resolved
+		})
+	}
+	return interpolatedKeys, unresolved
+}
+
+func resolvePlaceholder(provider string, path string) (string, bool) {
+	switch provider {
+	case "env":
+		return os.LookupEnv(path)
+	case "file":
+		// the key follows the last : so the path can contain them
+		i := strings.LastIndex(path, ":")
+		if i < 0 {
+			return "", false
+		}
+		properties, err := readProperties(path[:i])
+		if err != nil {
+			return "", false
+		}
+		val, ok := properties[path[i+1:]]
+		return val, ok
+	}
+	return "", false
+}
+
+// readProperties reads a java properties file of key=value or key: value lines
+func readProperties(fileName string) (map[string]string, error) {
+	if properties, ok := propertiesFiles[fileName]; ok {
+		return properties, nil
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			properties[line] = ""
+			continue
+		}
+		properties[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", fileName, err)
+	}
+
+	propertiesFiles[fileName] = properties
+	return properties, nil
+}
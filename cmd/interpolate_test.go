@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Interpolate(t *testing.T) {
+	os.Setenv("CONAN_TEST_DB_USER", "app")
+	defer os.Unsetenv("CONAN_TEST_DB_USER")
+	secrets := writeConfigFile(t, t.TempDir(), "db.properties", "# db secrets\ndb.password = s3cret\ndb.host: db1.internal\n")
+
+	config := map[string]string{
+		"connection.user": "${env:CONAN_TEST_DB_USER}",
+		"connection.pwd":  "${file:" + secrets + ":db.password}",
+		"jdbc.url":        "jdbc:postgresql://${file:" + secrets + ":db.host}:5432/db",
+		"topic.prefix":    "db1-",
+	}
+
+	interpolatedKeys, unresolved := interpolate(config)
+	assert.Empty(t, unresolved)
+	assert.Equal(t, "app", config["connection.user"])
+	assert.Equal(t, "s3cret", config["connection.pwd"])
+	assert.Equal(t, "jdbc:postgresql://db1.internal:5432/db", config["jdbc.url"])
+
+	// the resolved values are hidden, other values are not
+	assert.Equal(t, "***hidden***", cleanseVal("connection.pwd", config["connection.pwd"], interpolatedKeys))
+	assert.Equal(t, "***hidden***", cleanseVal("jdbc.url", config["jdbc.url"], interpolatedKeys))
+	assert.Equal(t, "db1-", cleanseVal("topic.prefix", config["topic.prefix"], interpolatedKeys))
+	// only this connector's interpolated keys are hidden
+	assert.Equal(t, "jdbc:postgresql://db2", cleanseVal("jdbc.url", "jdbc:postgresql://db2", nil))
+}
+
+func Test_InterpolateUnresolved(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.properties")
+	config := map[string]string{
+		"a": "${env:CONAN_TEST_NOT_SET}",
+		"b": "${file:" + missing + ":key}",
+		"c": "${file:no-key}",
+	}
+
+	_, unresolved := interpolate(config)
+	assert.ElementsMatch(t, []string{"${env:CONAN_TEST_NOT_SET}", "${file:" + missing + ":key}", "${file:no-key}"}, unresolved)
+	// unresolved placeholders are left for the worker's config providers
+	assert.Equal(t, "${env:CONAN_TEST_NOT_SET}", config["a"])
+}
+
+func Test_ReadConfigFileFailUnresolved(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "a.json", `{"name": "a", "connection.password": "${env:CONAN_TEST_NOT_SET}"}`)
+
+	failUnresolved = true
+	defer func() { failUnresolved = false }()
+
+	files := ReadConfigFile(path)
+	assert.Error(t, files[0].Error)
+}
+
+func Test_InterpolatedKeysAreHiddenPerConnector(t *testing.T) {
+	os.Setenv("CONAN_TEST_TOPIC", "orders")
+	defer os.Unsetenv("CONAN_TEST_TOPIC")
+	dir := t.TempDir()
+	a := ReadConfigFile(writeConfigFile(t, dir, "a.json", `{"name": "a", "topics": "${env:CONAN_TEST_TOPIC}"}`))[0]
+	b := ReadConfigFile(writeConfigFile(t, dir, "b.json", `{"name": "b", "topics": "payments"}`))[0]
+
+	deployed := map[string]string{"topics": "old"}
+	assert.Equal(t, MismatchVals{Deployed: "***hidden***", File: "***hidden***"}, DiffConfig("a", deployed, a.Config, a.InterpolatedKeys).MismatchKeys["topics"])
+	assert.Equal(t, MismatchVals{Deployed: "old", File: "payments"}, DiffConfig("b", deployed, b.Config, b.InterpolatedKeys).MismatchKeys["topics"])
+}
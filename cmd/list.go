@@ -18,6 +18,7 @@ package cmd
 import (
 	"strings"
 
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -39,28 +40,44 @@ var listCmd = &cobra.Command{
 	Long:   `List the connectors.`,
 	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
-		_ = List(cmd, args)
+		_, err := List(cmd, args)
+		cobra.CheckErr(err)
 	},
 }
 
-func List(cmd *cobra.Command, args []string) map[int]Connector {
-	host, port = GetPersistentFlags(cmd)
-	connectors := GetConnectorsMap(host, port)
+// List renders the connectors matching the name arg, --state-filter and --task-filter
+func List(cmd *cobra.Command, args []string) (map[int]Connector, error) {
+	connectors, err := FilterConnectors(GetClient(cmd), args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := render(cmd.OutOrStdout(), "ListTemplate", connectors); err != nil {
+		return nil, err
+	}
+
+	return connectors, nil
+}
 
+// FilterConnectors gets the details of the connectors whose name contains the first arg,
+// then keeps those with the --state-filter state and the --task-filter task summary
+func FilterConnectors(client *connect.Client, args []string) (map[int]Connector, error) {
 	// filter connectors by Name
+	filter := ""
 	if len(args) > 0 {
-		filteredConnectors := make(map[int]Connector)
-		for i, c := range connectors {
-			if strings.Contains(strings.ToLower(c.Name), strings.ToLower(args[0])) {
-				filteredConnectors[i] = c
-			}
-		}
+		filter = args[0]
+	}
 
-		connectors = filteredConnectors
-		log.Debug("connectors filtered by arg to ", connectors)
+	connectors, err := GetConnectorsWithStatus(client, filter)
+	if err != nil {
+		return nil, err
 	}
+	log.Debug("connectors filtered by arg to ", connectors)
 
-	connectors = GetConnectorsDetails(host, port, connectors)
+	connectors, err = addTaskConfigs(client, connectors)
+	if err != nil {
+		return nil, err
+	}
 
 	if stateFilter != "" {
 		filteredConnectors := make(map[int]Connector)
@@ -94,9 +111,22 @@ func List(cmd *cobra.Command, args []string) map[int]Connector {
 		log.Debug("connectors filtered by task-filter to ", connectors)
 	}
 
-	templates.ExecuteTemplate(cmd.OutOrStdout(), "ListTemplate", connectors)
+	return connectors, nil
+}
+
+// FilterConnectorsByName returns the connectors whose name contains the filter, ignoring case
+func FilterConnectorsByName(connectors map[int]Connector, filter string) map[int]Connector {
+	filteredConnectors := make(map[int]Connector)
+	for i, c := range connectors {
+		if matchesName(c.Name, filter) {
+			filteredConnectors[i] = c
+		}
+	}
+	return filteredConnectors
+}
 
-	return connectors
+func matchesName(connectorName string, filter string) bool {
+	return strings.Contains(strings.ToLower(connectorName), strings.ToLower(filter))
 }
 
 func init() {
@@ -104,6 +134,7 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().StringVarP(&taskFilter, "task-filter", "t", "", "a substring to filter task summaries by")
 	listCmd.Flags().StringVarP(&stateFilter, "state-filter", "s", "", "filter to connectors / tasks in this state")
+	listCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "the output format, one of text, json or yaml")
 
 	// Here you will define your flags and configuration settings.
 
@@ -16,7 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -24,13 +23,16 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -38,47 +40,127 @@ var (
 )
 
 type ConfigFile struct {
-	FileName       string
-	ConnectorName  string
-	ConnectorClass string
-	PluginClass    string
-	Config         map[string]string
-	ConfigBytes    []byte
-	ValidationResp ValidationResponse
-	LoadResp       *http.Response
-	Error          error
+	FileName       string            `json:"file_name"`
+	ConnectorName  string            `json:"connector_name"`
+	ConnectorClass string            `json:"connector_class"`
+	PluginClass    string            `json:"plugin_class"`
+	Config         map[string]string `json:"config"`
+	State          string            `json:"state,omitempty"`
+	Overlays       []string          `json:"overlays,omitempty"`
+	// InterpolatedKeys are the config keys whose values were resolved from placeholders
+	InterpolatedKeys map[string]bool            `json:"-"`
+	ConfigBytes      []byte                     `json:"-"`
+	ValidationResp   connect.ValidationResponse `json:"validation"`
+	LoadResp         *connect.Response          `json:"load,omitempty"`
+	Error            error                      `json:"-"`
+}
+
+// MarshalJSON hides secret config values and includes the Error message
+func (cf ConfigFile) MarshalJSON() ([]byte, error) {
+	type configFile ConfigFile
+
+	cf.Config = cleanseConfig(cf.Config, cf.InterpolatedKeys)
+
+	errMsg := ""
+	if cf.Error != nil {
+		errMsg = cf.Error.Error()
+	}
+
+	return json.Marshal(struct {
+		configFile
+		Error string `json:"error,omitempty"`
+	}{configFile(cf), errMsg})
 }
 
 func (cf *ConfigFile) FormattedStatus() string {
 	return FormatStatus(cf.LoadResp.Status, cf.LoadResp.StatusCode)
 }
 
-func (cf *ConfigFile) Read() {
-	configFile, _ := os.Open(cf.FileName)
-	byteValue, _ := ioutil.ReadAll(configFile)
+// configFileExtensions are the extensions of the config files read from a directory
+var configFileExtensions = []string{".json", ".yaml", ".yml", ".json.tmpl", ".yaml.tmpl", ".yml.tmpl"}
 
-	var configObj map[string]interface{}
-	err := json.Unmarshal([]byte(byteValue), &configObj)
+// isYaml reports whether a config file, or the config file rendered from a template, is yaml
+func isYaml(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(fileName, templateExtension)))
+	return ext == ".yaml" || ext == ".yml"
+}
 
+// ReadConfigFile reads the connectors defined in a json or yaml file, .tmpl files are rendered with the
+// --values first. A file can define a single connector or a list of them, each connector in a list
+// becomes its own ConfigFile named file[index]
+func ReadConfigFile(fileName string) []ConfigFile {
+	var byteValue []byte
+	var err error
+	if isTemplate(fileName) {
+		byteValue, err = renderTemplateFile(fileName)
+	} else {
+		byteValue, err = ioutil.ReadFile(fileName)
+	}
 	if err != nil {
-		cf.Error = err
-		return
+		return []ConfigFile{{FileName: fileName, Error: err}}
+	}
+
+	var content interface{}
+	if isYaml(fileName) {
+		err = yaml.Unmarshal(byteValue, &content)
+	} else {
+		err = json.Unmarshal(byteValue, &content)
+	}
+	if err != nil {
+		return []ConfigFile{{FileName: fileName, Error: err}}
 	}
 
+	switch c := content.(type) {
+	case map[string]interface{}:
+		cf := ConfigFile{FileName: fileName}
+		cf.parse(c, true)
+		return []ConfigFile{cf}
+
+	case []interface{}:
+		files := make([]ConfigFile, 0, len(c))
+		for i, entry := range c {
+			cf := ConfigFile{FileName: fmt.Sprintf("%s[%d]", fileName, i)}
+			if configObj, ok := entry.(map[string]interface{}); ok {
+				cf.parse(configObj, false)
+			} else {
+				cf.Error = fmt.Errorf("entry %d is not a connector definition", i)
+			}
+			files = append(files, cf)
+		}
+		return files
+	}
+	return []ConfigFile{{FileName: fileName, Error: fmt.Errorf("expected a connector definition or a list of them")}}
+}
+
+// parse sets the connector name, config and state from a connector definition,
+// for a file with a single connector its name can come from the file name
+func (cf *ConfigFile) parse(configObj map[string]interface{}, singleConnector bool) {
 	connectorName := strings.Split(filepath.Base(cf.FileName), ".")[0]
 
 	var conf = make(map[string]string)
 
-	// check the configured name matches the filename
-	configConnectorName := configObj["name"].(string)
-	if configConnectorName != connectorName {
+	configConnectorName, ok := configObj["name"].(string)
+	switch {
+	case ok && singleConnector && configConnectorName != connectorName:
+		// check the configured name matches the filename
 		log.Warnf("connector name [%s] does not match the name of the file [%s]", configConnectorName, cf.FileName)
+	case !ok && !singleConnector:
+		cf.Error = fmt.Errorf("connector definition has no name")
+		return
+	}
+	if ok {
+		connectorName = configConnectorName
 	}
-	connectorName = configConnectorName
 
-	// if there is a config sub object use it
-	if _, ok := configObj["config"]; ok {
-		configObj = configObj["config"].(map[string]interface{})
+	// if there is a config sub object use it, the desired state of the connector can then be set alongside it
+	if subObj, ok := configObj["config"]; ok {
+		if state, ok := configObj["state"].(string); ok {
+			cf.State = strings.ToUpper(state)
+		}
+		if configObj, ok = subObj.(map[string]interface{}); !ok {
+			cf.Error = fmt.Errorf("the config of connector %s is not an object", connectorName)
+			return
+		}
 	}
 
 	cf.ConnectorName = connectorName
@@ -92,20 +174,93 @@ func (cf *ConfigFile) Read() {
 			conf[k] = t
 		case float64:
 			conf[k] = strings.Trim(strings.Trim(fmt.Sprintf("%f", t), "0"), ".")
+		case bool:
+			conf[k] = strconv.FormatBool(t)
 		default:
 			log.Errorf("type of value for key %s is not understood", k)
 		}
 
 	}
-	log.Debugf("Conf is: %+v", conf)
+	interpolatedKeys, unresolved := interpolate(conf)
+	cf.InterpolatedKeys = interpolatedKeys
+	if len(unresolved) > 0 {
+		if failUnresolved {
+			cf.Error = fmt.Errorf("could not resolve %s", strings.Join(unresolved, ", "))
+			return
+		}
+		log.Warnf("could not resolve %s in connector %s, leaving them for the worker config providers", strings.Join(unresolved, ", "), connectorName)
+	}
 	cf.Config = conf
 
+	cf.setPluginClass()
+}
+
+// setPluginClass sets the ConnectorClass and PluginClass from the config
+func (cf *ConfigFile) setPluginClass() {
 	cf.ConnectorClass = cf.Config["connector.class"]
 	classParts := strings.Split(cf.ConnectorClass, ".")
 	cf.PluginClass = classParts[len(classParts)-1]
 
-	cf.ConfigBytes, _ = json.Marshal(conf)
+	cf.ConfigBytes, _ = json.Marshal(cf.Config)
+}
+
+// ReadConfigFiles reads the config files matching each of the glob paths,
+// a path to a directory reads each of the json and yaml config files in it.
+// The --overlay directories are then merged into them.
+func ReadConfigFiles(paths []string) []ConfigFile {
+	files, err := LoadConfigFiles(paths)
+	cobra.CheckErr(err)
+	return files
+}
+
+// LoadConfigFiles reads the config files and applies the --overlay directories to them
+func LoadConfigFiles(paths []string) ([]ConfigFile, error) {
+	files, err := readConfigFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyOverlays(files, overlays)
+}
+
+func readConfigFiles(paths []string) ([]ConfigFile, error) {
+	files := make([]ConfigFile, 0)
+
+	for _, path := range paths {
+		patterns := []string{path}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			patterns = nil
+			for _, ext := range configFileExtensions {
+				patterns = append(patterns, filepath.Join(path, "*"+ext))
+			}
+		}
+
+		var matches []string
+		for _, pattern := range patterns {
+			patternMatches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config file path %s: %w", pattern, err)
+			}
+			matches = append(matches, patternMatches...)
+		}
+		sort.Strings(matches)
+
+		if matches == nil {
+			log.Warn("no files found for arg ", path)
+		} else {
+			log.Debug("for arg ", path, " found files ", matches)
+			for _, file := range matches {
+				files = append(files, ReadConfigFile(file)...)
+			}
+		}
+	}
+	return files, nil
+}
 
+// addConfigFileFlags adds the flags that change how connector config files are read
+func addConfigFileFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&valuesFiles, "values", nil, "a yaml file of values to render .tmpl config files with, can be repeated")
+	cmd.Flags().StringArrayVar(&overlays, "overlay", nil, "a directory of connector config overrides to merge into the config files, can be repeated")
+	cmd.Flags().BoolVar(&failUnresolved, "fail-unresolved", false, "fail if a ${env:VAR} or ${file:/path:key} placeholder cannot be resolved")
 }
 
 // loadCmd represents the load command
@@ -122,161 +277,147 @@ var loadCmd = &cobra.Command{
 		}
 
 		// load the configs
-		files := make([]ConfigFile, 0)
-
-		for _, path := range args {
-			matches, err := filepath.Glob(path)
-
-			cobra.CheckErr(err)
-
-			if matches == nil {
-				log.Warn("no files found for arg ", path)
-			} else {
-				log.Debug("for arg ", path, " found files ", matches)
-				for _, file := range matches {
-					configFile := ConfigFile{FileName: file}
-					configFile.Read()
-					files = append(files, configFile)
-				}
-			}
-		}
+		files := ReadConfigFiles(args)
 
 		if len(files) == 0 {
 			fmt.Fprintf(cmd.OutOrStdout(), "No configuration files found for provided paths.\n")
 			return
 		}
 
-		rhttp := retryablehttp.NewClient()
+		client := GetRetryingClient(cmd)
 
-		// the retryablehttp client generates it's own logs that are not levelled
-		// the following prevents these logs from being outputted if the debg flag is not set
-		if !debug {
-			rhttp.Logger = golog.New(ioutil.Discard, "", golog.LstdFlags)
-		}
-		rhttp.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
-			log.Debugf("Making request %d to %s", attempt, req.URL)
-		}
-		rhttp.ResponseLogHook = func(_ retryablehttp.Logger, resp *http.Response) {
-			log.Debugf("received response from: %s status: %s", resp.Request.URL, resp.Status)
-		}
-
-		rhttp.RetryMax = 3
-		rhttp.RetryWaitMin = time.Duration(5 * time.Second)
-
-		// validate
-		var allValid = true
-		for i, file := range files {
-
-			if file.Error != nil {
-				allValid = false
-				continue
-			}
-
-			files[i].ValidationResp = ValidateConfig(rhttp, host, port, file)
-			if files[i].ValidationResp.ErrorCount > 0 {
-				allValid = false
-			}
-
-		}
+		allValid := ValidateConfigFiles(client, files)
 
 		if allValid && skipConfirm {
-			fmt.Fprintf(cmd.OutOrStdout(), "All connectors are valid. Loading configs.\n")
+			fmt.Fprintf(messageWriter(cmd), "All connectors are valid. Loading configs.\n")
 			for i, file := range files {
-				files[i].LoadResp = LoadConfig(rhttp, host, port, file)
+				files[i].LoadResp = LoadConfig(client, file)
 			}
 		} else if allValid {
-			err := templates.ExecuteTemplate(cmd.OutOrStdout(), "ValidationTemplate", files)
+			// the validation results are always shown as text before confirming
+			err := templates.ExecuteTemplate(messageWriter(cmd), "ValidationTemplate", files)
 
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStdout(), "Error rendering ValidationTemplate template %e.\n", err)
 				return
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "All connectors are valid. Load connectors? y/N ")
+			fmt.Fprintf(messageWriter(cmd), "All connectors are valid. Load connectors? y/N ")
 			if AwaitUserConfirm() {
-				fmt.Fprintf(cmd.OutOrStdout(), "Loading configs.\n")
+				fmt.Fprintf(messageWriter(cmd), "Loading configs.\n")
 				for i, file := range files {
-					files[i].LoadResp = LoadConfig(rhttp, host, port, file)
+					files[i].LoadResp = LoadConfig(client, file)
 				}
 			} else {
-				fmt.Fprintf(cmd.OutOrStdout(), "Skipped loading configs.\n")
+				fmt.Fprintf(messageWriter(cmd), "Skipped loading configs.\n")
 				return
 			}
 		}
 
-		err := templates.ExecuteTemplate(cmd.OutOrStdout(), "ValidationTemplate", files)
+		err := render(cmd.OutOrStdout(), "ValidationTemplate", files)
 
 		if err != nil {
 			fmt.Fprintf(cmd.OutOrStdout(), "Error rendering ValidationTemplate template %e.\n", err)
 			os.Exit(1)
 		}
 		if !allValid {
-			fmt.Fprintf(cmd.OutOrStdout(), "Validation errors found, skipped loading configs.\n")
+			fmt.Fprintf(messageWriter(cmd), "Validation errors found, skipped loading configs.\n")
+			os.Exit(1)
+		}
+
+		if wait && !waitAndReport(cmd, client, loadedConnectorNames(files), "RUNNING") {
 			os.Exit(1)
 		}
 	},
 }
 
-func LoadConfig(client *retryablehttp.Client, host string, port string, configFile ConfigFile) *http.Response {
-	validateUrl := fmt.Sprintf("http://%s:%s/connectors/%s/config", host, port, configFile.ConnectorName)
-
-	req, err := retryablehttp.NewRequest(http.MethodPut, validateUrl, bytes.NewBuffer(configFile.ConfigBytes))
-	cobra.CheckErr(err)
+// GetRetryingClient returns a client that retries failed requests, for loading configs
+// where a rebalance can cause requests to temporarily fail
+func GetRetryingClient(cmd *cobra.Command) *connect.Client {
+	rhttp := retryablehttp.NewClient()
 
-	req.Header.Set("Content-Type", "application/json")
+	// the retryablehttp client generates it's own logs that are not levelled
+	// the following prevents these logs from being outputted if the debg flag is not set
+	if !debug {
+		rhttp.Logger = golog.New(ioutil.Discard, "", golog.LstdFlags)
+	}
+	rhttp.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+		log.Debugf("Making request %d to %s", attempt, req.URL)
+	}
+	rhttp.ResponseLogHook = func(_ retryablehttp.Logger, resp *http.Response) {
+		log.Debugf("received response from: %s status: %s", resp.Request.URL, resp.Status)
+	}
 
-	resp, err := client.Do(req)
-	cobra.CheckErr(err)
-	respBodyBytes, _ := ioutil.ReadAll(resp.Body)
-	log.Debug(string(respBodyBytes))
-	log.Debug("Put config for: ", configFile.ConnectorName, " got response status: ", resp.Status, " and code: ", resp.StatusCode)
-	return resp
+	rhttp.RetryMax = 3
+	rhttp.RetryWaitMin = time.Duration(5 * time.Second)
 
+	// retry using the client's TLS configured http client
+	client := GetClient(cmd)
+	rhttp.HTTPClient = client.HTTPClient
+	client.HTTPClient = rhttp.StandardClient()
+	return client
 }
 
-func ValidateConfig(client *retryablehttp.Client, host string, port string, configFile ConfigFile) ValidationResponse {
-
-	validateUrl := fmt.Sprintf("http://%s:%s/connector-plugins/%s/config/validate", host, port, configFile.PluginClass)
-
-	req, err := retryablehttp.NewRequest(http.MethodPut, validateUrl, bytes.NewBuffer(configFile.ConfigBytes))
-	cobra.CheckErr(err)
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	cobra.CheckErr(err)
-	respBodyBytes, _ := ioutil.ReadAll(resp.Body)
-
-	log.Debug("Got response status: ", resp.StatusCode)
-	//fmt.Fprintf(cmd.OutOrStdout(), "response %s, StatusCode %d\n", string(respBodyBytes), resp.StatusCode)
-	var validationResponse ValidationResponse
+// ValidateConfigFiles validates each of the files that could be read and
+// reports whether they are all valid
+func ValidateConfigFiles(client *connect.Client, files []ConfigFile) bool {
+	var allValid = true
+	for i, file := range files {
 
-	json.Unmarshal(respBodyBytes, &validationResponse)
+		if file.Error != nil {
+			allValid = false
+			continue
+		}
 
-	// return ValidationResponse on a channel?
+		files[i].ValidationResp, files[i].Error = ValidateConfig(client, file)
+		if files[i].Error != nil || files[i].ValidationResp.ErrorCount > 0 {
+			allValid = false
+		}
 
-	return validationResponse
+	}
+	return allValid
 }
 
-type ValidationResponse struct {
-	ConnectorName string
-	Name          string
-	ErrorCount    int `json:"error_count"`
-	Configs       []ValidationResponseField
+func LoadConfig(client *connect.Client, configFile ConfigFile) *connect.Response {
+	resp, err := client.PutConnectorConfig(configFile.ConnectorName, configFile.Config)
+	if resp == nil {
+		// no response was received so there is no status to report
+		cobra.CheckErr(err)
+	}
+	if err != nil {
+		log.Debug(err)
+	}
+	log.Debug("Put config for: ", configFile.ConnectorName, " got response status: ", resp.Status, " and code: ", resp.StatusCode)
+	return resp
+
 }
 
-type ValidationResponseField struct {
-	Value ValidationResponseFieldValue
+// loadedConnectorNames returns the names of the connectors whose config was loaded successfully
+func loadedConnectorNames(files []ConfigFile) []string {
+	var names []string
+	for _, file := range files {
+		if file.LoadResp != nil && file.LoadResp.StatusCode < 300 {
+			names = append(names, file.ConnectorName)
+		}
+	}
+	return names
 }
 
-type ValidationResponseFieldValue struct {
-	Name   string
-	Errors []string
+func ValidateConfig(client *connect.Client, configFile ConfigFile) (connect.ValidationResponse, error) {
+	validationResponse, err := client.ValidateConfig(configFile.PluginClass, configFile.Config)
+	if err != nil {
+		return validationResponse, err
+	}
+	log.Debug("Validated config for: ", configFile.ConnectorName, " error count: ", validationResponse.ErrorCount)
+	return validationResponse, nil
 }
 
 func init() {
 	rootCmd.AddCommand(loadCmd)
 
 	loadCmd.Flags().BoolVarP(&skipConfirm, "skip-confirm", "f", false, "whether to prompt for confirmation when loading connectors")
+	loadCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "the output format, one of text, json or yaml")
+	addWaitFlags(loadCmd)
+	addConfigFileFlags(loadCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
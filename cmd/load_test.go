@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_ReadConfigFileJson(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "a.json", `{"name": "a", "state": "paused", "config": {"connector.class": "io.Sink", "tasks.max": 2, "enabled": true}}`)
+
+	files := ReadConfigFile(path)
+	assert.Len(t, files, 1)
+	assert.NoError(t, files[0].Error)
+	assert.Equal(t, "a", files[0].ConnectorName)
+	assert.Equal(t, "PAUSED", files[0].State)
+	assert.Equal(t, "Sink", files[0].PluginClass)
+	assert.Equal(t, map[string]string{"connector.class": "io.Sink", "tasks.max": "2", "enabled": "true"}, files[0].Config)
+}
+
+func Test_ReadConfigFileYamlList(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "db1.yaml", `
+- name: db1-table1
+  config:
+    connector.class: io.Source
+    tasks.max: 1
+    poll.interval.ms: 5000
+- name: db1-table2
+  connector.class: io.Source
+- config:
+    connector.class: io.Source
+`)
+
+	files := ReadConfigFile(path)
+	assert.Len(t, files, 3)
+
+	assert.NoError(t, files[0].Error)
+	assert.Equal(t, path+"[0]", files[0].FileName)
+	assert.Equal(t, "db1-table1", files[0].ConnectorName)
+	assert.Equal(t, map[string]string{"connector.class": "io.Source", "tasks.max": "1", "poll.interval.ms": "5000"}, files[0].Config)
+
+	assert.NoError(t, files[1].Error)
+	assert.Equal(t, "db1-table2", files[1].ConnectorName)
+	assert.Equal(t, "io.Source", files[1].Config["connector.class"])
+
+	// connectors in a list must be named
+	assert.Error(t, files[2].Error)
+}
+
+func Test_ReadConfigFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	files := ReadConfigFile(writeConfigFile(t, dir, "bad.json", `{"name": `))
+	assert.Len(t, files, 1)
+	assert.Error(t, files[0].Error)
+
+	files = ReadConfigFile(writeConfigFile(t, dir, "scalar.yml", `just a string`))
+	assert.Error(t, files[0].Error)
+
+	files = ReadConfigFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, files[0].Error)
+}
+
+func Test_ReadConfigFilesFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "b.yml", `{name: b, connector.class: io.Sink}`)
+	writeConfigFile(t, dir, "a.json", `{"name": "a", "connector.class": "io.Sink"}`)
+	writeConfigFile(t, dir, "c.yaml", "- {name: c1, connector.class: io.Sink}\n- {name: c2, connector.class: io.Sink}")
+	writeConfigFile(t, dir, "README.md", `not a config file`)
+
+	files := ReadConfigFiles([]string{dir})
+	var names []string
+	for _, file := range files {
+		assert.NoError(t, file.Error)
+		names = append(names, file.ConnectorName)
+	}
+	assert.Equal(t, []string{"a", "b", "c1", "c2"}, names)
+}
@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jack-tee/conan/connect"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	offsetsFile      string
+	offsetsExportDir string
+)
+
+var (
+	Stop         Operation = Operation{"stop", http.MethodPut, "stop"}
+	GetOffsets   Operation = Operation{"get offsets for", http.MethodGet, "offsets"}
+	ResetOffsets Operation = Operation{"reset offsets for", http.MethodDelete, "offsets"}
+	AlterOffsets Operation = Operation{"alter offsets for", http.MethodPatch, "offsets"}
+)
+
+// ConnectorOffsets are the committed offsets of a connector
+type ConnectorOffsets struct {
+	ConnectorId   int                       `json:"connector_id"`
+	ConnectorName string                    `json:"connector_name"`
+	Offsets       []connect.ConnectorOffset `json:"offsets"`
+}
+
+// FormattedOffsets returns each partition and its offset as json
+func (o ConnectorOffsets) FormattedOffsets() []string {
+	var formatted []string
+	for _, offset := range o.Offsets {
+		partition, _ := toJson(offset.Partition)
+		value, _ := toJson(offset.Offset)
+		formatted = append(formatted, fmt.Sprintf("%s -> %s", partition, value))
+	}
+	return formatted
+}
+
+var offsetsCmd = &cobra.Command{
+	Use:   "offsets",
+	Short: "Get, reset or alter the offsets of connectors",
+	Long: `Get, reset or alter the committed offsets of connectors using the offsets API of Kafka Connect 3.6+.
+Resetting or altering the offsets stops the connector, saves its previous offsets to a file and then
+returns the connector to its previous state.`,
+}
+
+var getOffsetsCmd = &cobra.Command{
+	Use:    "get [filter]",
+	Short:  "Show the offsets of connectors",
+	Long:   `Show the committed offsets of connectors.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := GetClient(cmd)
+		checkOffsetsSupported(client)
+
+		connectors, err := List(cmd, args)
+		cobra.CheckErr(err)
+
+		var offsets []ConnectorOffsets
+		for _, connector := range selectOffsetsConnectors(cmd, connectors, GetOffsets) {
+			o, err := GetConnectorOffsets(client, connector)
+			cobra.CheckErr(err)
+			offsets = append(offsets, o)
+
+			if cmd.Flags().Changed("export-dir") {
+				filename, err := exportOffsets(offsetsExportDir, o)
+				cobra.CheckErr(err)
+				fmt.Fprintf(cmd.OutOrStdout(), "Saved the offsets of %s to %s\n", connector.Name, filename)
+			}
+		}
+		cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OffsetsTemplate", offsets))
+	},
+}
+
+var resetOffsetsCmd = &cobra.Command{
+	Use:    "reset [filter]",
+	Short:  "Reset the offsets of connectors",
+	Long:   `Stop connectors, save their offsets to a file, reset their offsets and then return them to their previous state.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		offsetsCommand(cmd, ResetOffsets, args, nil)
+	},
+}
+
+var alterOffsetsCmd = &cobra.Command{
+	Use:   "alter [filter] --file offsets.json",
+	Short: "Alter the offsets of a connector",
+	Long: `Stop a connector, save its offsets to a file, apply the offsets in --file and then return it to its previous state.
+The file has the format of the offsets API e.g {"offsets": [{"partition": {...}, "offset": {...}}]}, a null offset resets
+the offset of the partition. The file of previous offsets saved by reset or alter can be applied to roll back.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		patch, err := ReadOffsetsFile(offsetsFile)
+		cobra.CheckErr(err)
+		offsetsCommand(cmd, AlterOffsets, args, &patch)
+	},
+}
+
+func offsetsCommand(cmd *cobra.Command, op Operation, args []string, patch *connect.ConnectorOffsets) {
+	client := GetClient(cmd)
+	checkOffsetsSupported(client)
+
+	if !assumeYes && !IsInteractive() {
+		cobra.CheckErr(fmt.Errorf("stdin is not a terminal so cannot confirm, use --yes to %s the connectors", op.Mode))
+	}
+
+	connectors, err := List(cmd, args)
+	cobra.CheckErr(err)
+
+	selected := selectOffsetsConnectors(cmd, connectors, op)
+	if op == AlterOffsets && len(selected) > 1 {
+		cobra.CheckErr(fmt.Errorf("the offsets in %s can only be applied to a single connector, %d were selected", offsetsFile, len(selected)))
+	}
+
+	var results OperationResults
+	for _, connector := range selected {
+		results = append(results, ModifyOffsets(cmd, client, op, connector, patch)...)
+	}
+
+	if len(results) > 0 {
+		cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OperationResultTemplate", results))
+	}
+	if results.Failed() > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkOffsetsSupported exits when the worker is older than Kafka 3.6 which added the offsets API
+func checkOffsetsSupported(client *connect.Client) {
+	info, err := GetServerInfo(client)
+	cobra.CheckErr(err)
+	if !info.AtLeast(3, 6) {
+		cobra.CheckErr(fmt.Errorf("managing offsets requires Kafka Connect 3.6 or later, the worker is version %s", info.Version))
+	}
+}
+
+// selectOffsetsConnectors returns the connectors selected by the --all or --ids flags or prompts the user to select them
+func selectOffsetsConnectors(cmd *cobra.Command, connectors map[int]Connector, op Operation) []Connector {
+	quit, all, connectorIdsSelected := selectConnectors(cmd, op)
+	if quit {
+		fmt.Fprintf(cmd.OutOrStdout(), "Quitting.\n")
+		return nil
+	}
+	if all {
+		return SortedConnectors(connectors)
+	}
+
+	var selected []Connector
+	for _, s := range connectorIdsSelected {
+		connector, ok := connectors[s.ConnectorId]
+		if !ok {
+			cobra.CheckErr(fmt.Errorf("connectorId [%d] not found in connectors", s.ConnectorId))
+		}
+		selected = append(selected, connector)
+	}
+	return selected
+}
+
+// GetConnectorOffsets gets the committed offsets of the connector
+func GetConnectorOffsets(client *connect.Client, connector Connector) (ConnectorOffsets, error) {
+	offsets, err := client.ConnectorOffsets(connector.Name)
+	return ConnectorOffsets{ConnectorId: connector.Id, ConnectorName: connector.Name, Offsets: offsets.Offsets}, err
+}
+
+// ModifyOffsets stops the connector, saves its offsets to a file and after confirmation resets them or applies
+// the patch, the connector is then returned to its previous state
+func ModifyOffsets(cmd *cobra.Command, client *connect.Client, op Operation, connector Connector, patch *connect.ConnectorOffsets) OperationResults {
+	var results OperationResults
+	record := func(operation string, resp *connect.Response, err error) bool {
+		result := OperationResult{ConnectorId: connector.Id, ConnectorName: connector.Name, TaskId: -1, Operation: operation}
+		result.complete(resp, err)
+		results = append(results, result)
+		return result.Succeeded()
+	}
+
+	previousState := connector.Details.Connector.State
+	if previousState != "STOPPED" {
+		resp, err := client.StopConnector(connector.Name)
+		if !record(Stop.Mode, resp, err) {
+			return results
+		}
+		// the offsets can only be changed once the connector and its tasks have stopped
+		stopped := WaitForState(client, []string{connector.Name}, "STOPPED", waitTimeout)
+		if stopped.NotReached() > 0 {
+			cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "WaitResultTemplate", stopped))
+			// the stop request succeeded but its result is that the connector did not stop
+			results[len(results)-1].Error = fmt.Sprintf("connector did not reach STOPPED within %s", waitTimeout)
+			return append(results, restoreState(client, connector, previousState)...)
+		}
+	}
+
+	if modifyConnectorOffsets(cmd, client, op, connector, patch, record) {
+		fmt.Fprintf(cmd.OutOrStdout(), "Skipped changing the offsets of %s.\n", connector.Name)
+	}
+	return append(results, restoreState(client, connector, previousState)...)
+}
+
+// modifyConnectorOffsets saves the offsets of the stopped connector and after confirmation changes them,
+// it reports whether the change was skipped
+func modifyConnectorOffsets(cmd *cobra.Command, client *connect.Client, op Operation, connector Connector, patch *connect.ConnectorOffsets,
+	record func(string, *connect.Response, error) bool) bool {
+
+	offsets, err := GetConnectorOffsets(client, connector)
+	if err != nil {
+		record("get", nil, err)
+		return false
+	}
+	cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OffsetsTemplate", []ConnectorOffsets{offsets}))
+
+	filename, err := exportOffsets(offsetsExportDir, offsets)
+	if err != nil {
+		// without the previous offsets the change could not be rolled back
+		record("export", nil, err)
+		return false
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Saved the previous offsets of %s to %s\n", connector.Name, filename)
+
+	if patch != nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "\nNew offsets from %s:\n", offsetsFile)
+		cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OffsetsTemplate", []ConnectorOffsets{{ConnectorId: connector.Id, ConnectorName: connector.Name, Offsets: patch.Offsets}}))
+	}
+
+	if !assumeYes {
+		if op == AlterOffsets {
+			fmt.Fprintf(cmd.OutOrStdout(), "Apply the new offsets to %s? y/N ", connector.Name)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Reset the offsets of %s? y/N ", connector.Name)
+		}
+		if !AwaitUserConfirm() {
+			return true
+		}
+	}
+
+	if op == AlterOffsets {
+		resp, err := client.AlterConnectorOffsets(connector.Name, *patch)
+		record("alter", resp, err)
+	} else {
+		resp, err := client.ResetConnectorOffsets(connector.Name)
+		record("reset", resp, err)
+	}
+	return false
+}
+
+// restoreState returns the connector to its state before it was stopped, a connector that was
+// FAILED or UNASSIGNED is resumed
+func restoreState(client *connect.Client, connector Connector, previousState string) OperationResults {
+	op := Resume
+	switch previousState {
+	case "STOPPED":
+		return nil
+	case "PAUSED":
+		op = Pause
+	}
+	log.Debug(fmt.Sprintf("returning connector %s to %s", connector.Name, previousState))
+	result := newOperationResult(op, connector, -1)
+	result.complete(ExecuteConnectorOp(client, op, connector.Name))
+	return OperationResults{result}
+}
+
+// offsetsFileTimeLayout is the time in the names of the offsets files, it has no ':' as that is not allowed in Windows file names
+const offsetsFileTimeLayout = "20060102T150405Z"
+
+// exportOffsets writes the offsets to a file in dir in the format alter reads
+func exportOffsets(dir string, offsets ConnectorOffsets) (string, error) {
+	filename := filepath.Join(dir, fmt.Sprintf("conan-offsets-%s-%s.json", safeFileName(offsets.ConnectorName), time.Now().UTC().Format(offsetsFileTimeLayout)))
+	b, err := json.MarshalIndent(connect.ConnectorOffsets{Offsets: offsets.Offsets}, "", "  ")
+	if err != nil {
+		return filename, err
+	}
+	return filename, ioutil.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+// ReadOffsetsFile reads the offsets to apply to a connector
+func ReadOffsetsFile(filename string) (connect.ConnectorOffsets, error) {
+	var offsets connect.ConnectorOffsets
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return offsets, err
+	}
+	if err := json.Unmarshal(b, &offsets); err != nil {
+		return offsets, fmt.Errorf("could not read offsets file %s: %w", filename, err)
+	}
+	if len(offsets.Offsets) == 0 {
+		return offsets, fmt.Errorf("offsets file %s has no offsets, expected {\"offsets\": [{\"partition\": {...}, \"offset\": {...}}]}", filename)
+	}
+	return offsets, nil
+}
+
+func init() {
+	rootCmd.AddCommand(offsetsCmd)
+	offsetsCmd.AddCommand(getOffsetsCmd)
+	offsetsCmd.AddCommand(resetOffsetsCmd)
+	offsetsCmd.AddCommand(alterOffsetsCmd)
+
+	for _, c := range []*cobra.Command{getOffsetsCmd, resetOffsetsCmd, alterOffsetsCmd} {
+		c.Flags().StringVarP(&taskFilter, "task-filter", "t", "", "a substring to filter task summaries by")
+		c.Flags().StringVarP(&stateFilter, "state-filter", "s", "", "filter to connectors / tasks in this state")
+		c.Flags().StringVar(&selectedIds, "ids", "", "the connectorIds to operate on e.g 2,5,7 rather than prompting")
+		c.Flags().BoolVar(&selectAll, "all", false, "operate on all LISTED connectors rather than prompting")
+	}
+	getOffsetsCmd.Flags().StringVar(&offsetsExportDir, "export-dir", ".", "save the offsets of each connector to a file in this directory")
+
+	for _, c := range []*cobra.Command{resetOffsetsCmd, alterOffsetsCmd} {
+		c.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not prompt for confirmation")
+		c.Flags().StringVar(&offsetsExportDir, "export-dir", ".", "the directory to save the previous offsets of each connector to before changing them")
+		c.Flags().DurationVar(&waitTimeout, "timeout", 2*time.Minute, "how long to wait for the connector to stop e.g 30s, 5m")
+	}
+
+	alterOffsetsCmd.Flags().StringVarP(&offsetsFile, "file", "f", "", "a json file of the offsets to apply")
+	cobra.CheckErr(alterOffsetsCmd.MarkFlagRequired("file"))
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jack-tee/conan/connect"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOffsets returns a client for a worker with a single connector "a" in the state that can be stopped and
+// whose offsets can only be changed once it is STOPPED, the requests made are recorded.
+// The first status after the stop request still has the previous state as stopping is asynchronous
+func fakeOffsets(t *testing.T, state string, requests *[]string) *connect.Client {
+	var mu sync.Mutex
+	stopping := false
+	offsets := `{"offsets":[{"partition":{"table":"t"},"offset":{"incrementing":42}}]}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+
+		switch r.URL.Path {
+		case "/connectors/a/status":
+			tasks := fmt.Sprintf(`[{"id":0,"state":"%s","worker_id":"w1"}]`, state)
+			if state == "STOPPED" {
+				tasks = `[]`
+			}
+			fmt.Fprintf(w, `{"name":"a","connector":{"state":"%s","worker_id":"w1"},"tasks":%s}`, state, tasks)
+			if stopping {
+				state = "STOPPED"
+				stopping = false
+			}
+		case "/connectors/a/stop":
+			stopping = true
+			w.WriteHeader(http.StatusAccepted)
+		case "/connectors/a/resume":
+			state = "RUNNING"
+			w.WriteHeader(http.StatusAccepted)
+		case "/connectors/a/offsets":
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, offsets)
+				return
+			}
+			if state != "STOPPED" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			offsets = `{"offsets":[]}`
+			fmt.Fprint(w, `{"message":"The offsets for this connector have been reset successfully"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return connect.NewClient(server.URL)
+}
+
+// restoreOffsetsGlobals restores the globals the offsets tests change once the test finishes
+func restoreOffsetsGlobals(t *testing.T) {
+	interval, timeout, yes, dir := waitPollInterval, waitTimeout, assumeYes, offsetsExportDir
+	t.Cleanup(func() {
+		waitPollInterval, waitTimeout, assumeYes, offsetsExportDir = interval, timeout, yes, dir
+	})
+}
+
+func Test_ExportOffsetsFileName(t *testing.T) {
+	dir := t.TempDir()
+	filename, err := exportOffsets(dir, ConnectorOffsets{ConnectorName: "db1/table1"})
+	assert.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(filename))
+	assert.True(t, strings.HasPrefix(filepath.Base(filename), "conan-offsets-db1_table1-"), filename)
+	assert.NotContains(t, filepath.Base(filename), ":")
+}
+
+func Test_ModifyOffsetsStopTimedOut(t *testing.T) {
+	toggleDebug(nil, nil)
+	restoreOffsetsGlobals(t)
+	waitPollInterval = time.Millisecond
+	waitTimeout = 10 * time.Millisecond
+	assumeYes = true
+	offsetsExportDir = t.TempDir()
+
+	// the connector never stops
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/connectors/a/status" {
+			fmt.Fprint(w, `{"name":"a","connector":{"state":"RUNNING","worker_id":"w1"},"tasks":[{"id":0,"state":"RUNNING","worker_id":"w1"}]}`)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	cmd := &cobra.Command{}
+	cmd.SetOut(bytes.NewBufferString(""))
+
+	connector := Connector{Id: 0, Name: "a", Details: ConnectorDetails{Connector: ConnectorState{State: "RUNNING"}}}
+	results := ModifyOffsets(cmd, connect.NewClient(server.URL), ResetOffsets, connector, nil)
+
+	// a single stop result that failed, then the connector is resumed
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "stop", results[0].Operation)
+		assert.Contains(t, results[0].Error, "did not reach STOPPED")
+		assert.Equal(t, "resume", results[1].Operation)
+		assert.True(t, results[1].Succeeded())
+	}
+}
+
+func Test_ModifyOffsetsReset(t *testing.T) {
+	toggleDebug(nil, nil)
+	restoreOffsetsGlobals(t)
+	waitPollInterval = time.Millisecond
+	assumeYes = true
+	offsetsExportDir = t.TempDir()
+
+	var requests []string
+	client := fakeOffsets(t, "RUNNING", &requests)
+	cmd := &cobra.Command{}
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+
+	connector := Connector{Id: 0, Name: "a", Details: ConnectorDetails{Connector: ConnectorState{State: "RUNNING"}}}
+	results := ModifyOffsets(cmd, client, ResetOffsets, connector, nil)
+
+	assert.Equal(t, 0, results.Failed())
+	var operations []string
+	for _, r := range results {
+		operations = append(operations, r.Operation)
+	}
+	assert.Equal(t, []string{"stop", "reset", "resume"}, operations)
+	assert.Contains(t, requests, "DELETE /connectors/a/offsets")
+	assert.Contains(t, b.String(), `{"table":"t"} -> {"incrementing":42}`)
+
+	// the previous offsets are saved in the format alter reads to roll back
+	files, err := filepath.Glob(filepath.Join(offsetsExportDir, "*.json"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	previous, err := ReadOffsetsFile(files[0])
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"incrementing": float64(42)}, previous.Offsets[0].Offset)
+}
+
+func Test_RestoreState(t *testing.T) {
+	var requests []string
+	client := fakeOffsets(t, "RUNNING", &requests)
+	connector := Connector{Id: 0, Name: "a"}
+
+	assert.Nil(t, restoreState(client, connector, "STOPPED"))
+	assert.Equal(t, "resume", restoreState(client, connector, "FAILED")[0].Operation)
+	assert.Equal(t, "pause", restoreState(client, connector, "PAUSED")[0].Operation)
+}
+
+func Test_ReadOffsetsFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := writeConfigFile(t, dir, "offsets.json", `{"offsets":[{"partition":{"table":"t"},"offset":null}]}`)
+
+	offsets, err := ReadOffsetsFile(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, offsets.Offsets[0].Offset)
+
+	// a null offset is sent to reset the partition
+	b, err := json.Marshal(offsets)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"offsets":[{"partition":{"table":"t"},"offset":null}]}`, string(b))
+
+	_, err = ReadOffsetsFile(writeConfigFile(t, dir, "empty.json", `{"offsets":[]}`))
+	assert.Error(t, err)
+}
+
+func Test_ModifyOffsetsResetFailedConnector(t *testing.T) {
+	toggleDebug(nil, nil)
+	restoreOffsetsGlobals(t)
+	waitPollInterval = time.Millisecond
+	assumeYes = true
+	offsetsExportDir = t.TempDir()
+
+	var requests []string
+	client := fakeOffsets(t, "FAILED", &requests)
+	cmd := &cobra.Command{}
+	cmd.SetOut(bytes.NewBufferString(""))
+
+	// the FAILED connector is still reported as FAILED straight after the stop request
+	connector := Connector{Id: 0, Name: "a", Details: ConnectorDetails{Connector: ConnectorState{State: "FAILED"}}}
+	results := ModifyOffsets(cmd, client, ResetOffsets, connector, nil)
+
+	assert.Equal(t, 0, results.Failed())
+	var operations []string
+	for _, r := range results {
+		operations = append(operations, r.Operation)
+	}
+	assert.Equal(t, []string{"stop", "reset", "resume"}, operations)
+	assert.Contains(t, requests, "DELETE /connectors/a/offsets")
+}
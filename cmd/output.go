@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var outputFormat string
+
+// render writes data using the named template, or serialized as json or yaml when --output is set
+func render(w io.Writer, templateName string, data interface{}) error {
+	switch outputFormat {
+	case "", "text":
+		return templates.ExecuteTemplate(w, templateName, data)
+	case "json":
+		return writeJson(w, serializable(data))
+	case "yaml":
+		return writeYaml(w, serializable(data))
+	}
+	return fmt.Errorf("unknown output format [%s] expected one of text, json or yaml", outputFormat)
+}
+
+// structuredOutput reports whether the output is being serialized
+func structuredOutput() bool {
+	return outputFormat == "json" || outputFormat == "yaml"
+}
+
+// messageWriter returns where to write progress messages so they don't end up
+// in the middle of json or yaml output
+func messageWriter(cmd *cobra.Command) io.Writer {
+	if structuredOutput() {
+		return cmd.ErrOrStderr()
+	}
+	return cmd.OutOrStdout()
+}
+
+// serializable converts a map of connectors to a slice ordered by connectorId
+func serializable(data interface{}) interface{} {
+	if connectors, ok := data.(map[int]Connector); ok {
+		return SortedConnectors(connectors)
+	}
+	return data
+}
+
+// SortedConnectors returns the connectors ordered by connectorId
+func SortedConnectors(connectors map[int]Connector) []Connector {
+	sorted := make([]Connector, 0, len(connectors))
+	for _, c := range connectors {
+		sorted = append(sorted, c)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
+	return sorted
+}
+
+func writeJson(w io.Writer, data interface{}) error {
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// writeYaml converts data to yaml via json so the json field names are used
+// and the fields stay in the order they are declared
+func writeYaml(w io.Writer, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle clears the flow style that nodes decoded from json have
+func blockStyle(node *yaml.Node) {
+	node.Style = node.Style &^ yaml.FlowStyle
+	if node.Kind == yaml.ScalarNode && node.Style&yaml.DoubleQuotedStyle != 0 {
+		node.Style = 0
+	}
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderConnectorsAsJsonInIdOrder(t *testing.T) {
+	outputFormat = "json"
+	defer func() { outputFormat = "" }()
+
+	connectors := map[int]Connector{
+		10: {Id: 10, Name: "b"},
+		2:  {Id: 2, Name: "a"},
+	}
+	var b bytes.Buffer
+	assert.NoError(t, render(&b, "ListTemplate", connectors))
+
+	out := b.String()
+	assert.Less(t, bytes.Index(b.Bytes(), []byte(`"id": 2`)), bytes.Index(b.Bytes(), []byte(`"id": 10`)))
+	assert.Contains(t, out, `"name": "a"`)
+}
+
+func Test_RenderYamlKeepsStringTypes(t *testing.T) {
+	outputFormat = "yaml"
+	defer func() { outputFormat = "" }()
+
+	var b bytes.Buffer
+	assert.NoError(t, render(&b, "DiffTemplate", MismatchVals{Deployed: "5000", File: "true"}))
+	assert.Equal(t, "deployed: \"5000\"\nfile: \"true\"\n", b.String())
+}
+
+func Test_RenderUnknownFormat(t *testing.T) {
+	outputFormat = "xml"
+	defer func() { outputFormat = "" }()
+
+	assert.Error(t, render(&bytes.Buffer{}, "ListTemplate", nil))
+}
+
+func Test_RenderConnectorsHidesSecrets(t *testing.T) {
+	outputFormat = "json"
+	defer func() { outputFormat = "" }()
+
+	config := map[string]string{"connection.password": "hunter2", "connection.url": "jdbc:postgresql://db", "topics": "t"}
+	connectors := map[int]Connector{
+		0: {Id: 0, Name: "a", Details: ConnectorDetails{Config: config, Tasks: []TaskState{{Id: 0, Config: config}}}},
+	}
+	var b bytes.Buffer
+	assert.NoError(t, render(&b, "ListTemplate", connectors))
+
+	out := b.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "jdbc:postgresql")
+	assert.Contains(t, out, `"connection.password": "***hidden***"`)
+	assert.Contains(t, out, `"topics": "t"`)
+	// the connector's config is not changed
+	assert.Equal(t, "hunter2", connectors[0].Details.Config["connection.password"])
+}
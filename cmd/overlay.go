@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var overlays []string
+
+// ApplyOverlays merges the config of the connectors in each overlay directory, in order, into the
+// connectors of the same name in files. An overlay can also override the state of a connector.
+func ApplyOverlays(files []ConfigFile, overlayDirs []string) ([]ConfigFile, error) {
+	for _, dir := range overlayDirs {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("could not read overlay: %w", err)
+		}
+
+		overlayFiles, err := readConfigFiles([]string{dir})
+		if err != nil {
+			return nil, err
+		}
+		if len(overlayFiles) == 0 {
+			return nil, fmt.Errorf("no overlay files found in %s", dir)
+		}
+
+		for _, overlay := range overlayFiles {
+			if overlay.Error != nil {
+				return nil, fmt.Errorf("could not read overlay %s: %w", overlay.FileName, overlay.Error)
+			}
+
+			found := false
+			for i := range files {
+				if files[i].ConnectorName == overlay.ConnectorName && files[i].Error == nil {
+					files[i].merge(overlay)
+					found = true
+				}
+			}
+			if !found {
+				log.Warnf("overlay %s is for connector %s which is not in the config files", overlay.FileName, overlay.ConnectorName)
+			}
+		}
+	}
+	return files, nil
+}
+
+// merge overrides the config keys and state of the connector with those of the overlay
+func (cf *ConfigFile) merge(overlay ConfigFile) {
+	log.Debugf("applying overlay %s to %s", overlay.FileName, cf.FileName)
+
+	for k, v := range overlay.Config {
+		cf.Config[k] = v
+	}
+	for k := range overlay.InterpolatedKeys {
+		if cf.InterpolatedKeys == nil {
+			cf.InterpolatedKeys = make(map[string]bool)
+		}
+		cf.InterpolatedKeys[k] = true
+	}
+	if overlay.State != "" {
+		cf.State = overlay.State
+	}
+	cf.Overlays = append(cf.Overlays, overlay.FileName)
+	cf.setPluginClass()
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ApplyOverlays(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "db1.yaml", `
+- name: db1-table1
+  config:
+    connector.class: io.Source
+    connection.url: jdbc:postgresql://dev-db:5432/db1
+    poll.interval.ms: 1000
+- name: db1-table2
+  config:
+    connector.class: io.Source
+    connection.url: jdbc:postgresql://dev-db:5432/db1
+`)
+	prod := filepath.Join(dir, "envs", "prod")
+	if err := os.MkdirAll(prod, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFile(t, prod, "db1-table1.json", `{"name": "db1-table1", "state": "paused", "config": {"connection.url": "jdbc:postgresql://prod-db:5432/db1", "poll.interval.ms": 60000}}`)
+	writeConfigFile(t, prod, "unknown.json", `{"name": "unknown", "topic.prefix": "prod-"}`)
+
+	files, err := readConfigFiles([]string{base})
+	assert.NoError(t, err)
+	files, err = ApplyOverlays(files, []string{prod})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"connector.class":  "io.Source",
+		"connection.url":   "jdbc:postgresql://prod-db:5432/db1",
+		"poll.interval.ms": "60000",
+	}, files[0].Config)
+	assert.Equal(t, "PAUSED", files[0].State)
+	assert.Equal(t, "Source", files[0].PluginClass)
+	assert.Equal(t, []string{filepath.Join(prod, "db1-table1.json")}, files[0].Overlays)
+
+	assert.Equal(t, "jdbc:postgresql://dev-db:5432/db1", files[1].Config["connection.url"])
+	assert.Empty(t, files[1].Overlays)
+}
+
+func Test_ApplyOverlaysMissingDir(t *testing.T) {
+	_, err := ApplyOverlays(nil, []string{filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
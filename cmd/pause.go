@@ -17,9 +17,11 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"net/http"
+	"os"
+	"strconv"
 
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -36,6 +38,13 @@ var (
 	onlyTasks   bool = false
 )
 
+var (
+	selectedIds   string = ""
+	selectedTasks string = ""
+	selectAll     bool   = false
+	assumeYes     bool   = false
+)
+
 var (
 	Pause   Operation = Operation{"pause", http.MethodPut, "pause"}
 	Resume  Operation = Operation{"resume", http.MethodPut, "resume"}
@@ -84,8 +93,9 @@ var restartCmd = &cobra.Command{
 }
 
 func opCommand(cmd *cobra.Command, op Operation, args []string) {
-	connectors := List(cmd, args)
-	executeConnectorOperation(cmd, connectors, op)
+	connectors, err := List(cmd, args)
+	cobra.CheckErr(err)
+	executeConnectorOperation(cmd, GetClient(cmd), connectors, op)
 }
 
 func init() {
@@ -108,6 +118,17 @@ func init() {
 	restartCmd.Flags().BoolVar(&allTasks, "all-tasks", false, "also restart the connector's tasks")
 	restartCmd.Flags().BoolVar(&failedTasks, "failed-tasks", true, "also restart the connector's failed tasks")
 	restartCmd.Flags().BoolVar(&onlyTasks, "only-tasks", false, "only restart the connector's tasks, not the connector itself")
+	restartCmd.Flags().StringVar(&selectedTasks, "tasks", "", "the connectorId.taskId of individual tasks to restart e.g 3.1,3.4 rather than prompting")
+
+	for _, c := range []*cobra.Command{pauseCmd, resumeCmd, restartCmd} {
+		addWaitFlags(c)
+	}
+
+	for _, c := range []*cobra.Command{pauseCmd, resumeCmd, deleteCmd, restartCmd} {
+		c.Flags().StringVar(&selectedIds, "ids", "", "the connectorIds to operate on e.g 2,5,7 rather than prompting")
+		c.Flags().BoolVar(&selectAll, "all", false, "operate on all LISTED connectors rather than prompting")
+		c.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not prompt for confirmation")
+	}
 
 	// Here you will define your flags and configuration settings.
 
@@ -120,45 +141,232 @@ func init() {
 	// pauseCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func executeConnectorOperation(cmd *cobra.Command, connectors map[int]Connector, op Operation) {
-	fmt.Fprintf(cmd.OutOrStdout(), "Enter a connectorId to %s it e.g 4, enter all to %s all LISTED connectors or q to quit:\n", op.Mode, op.Mode)
+func executeConnectorOperation(cmd *cobra.Command, client *connect.Client, connectors map[int]Connector, op Operation) {
+	quit, opAll, connectorIdsSelected := selectConnectors(cmd, op)
 
-	quit, opAll, connectorIdsSelected := AwaitConnectorInput()
+	var results OperationResults
 
 	if quit {
 		fmt.Fprintf(cmd.OutOrStdout(), "Quitting.\n")
 		return
 
 	} else if opAll {
-		fmt.Fprintf(cmd.OutOrStdout(), "%s all LISTED connectors? Enter y to confirm:\n", op.Mode)
+		if !assumeYes {
+			if !IsInteractive() {
+				cobra.CheckErr(fmt.Errorf("stdin is not a terminal so cannot confirm, use --yes to %s all LISTED connectors", op.Mode))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s all LISTED connectors? Enter y to confirm:\n", op.Mode)
 
-		if AwaitUserConfirm() {
-			for id, connector := range connectors {
-				ExecuteOp(op, host, port, connector)
-				fmt.Fprintf(cmd.OutOrStdout(), "Connector %d %s %sd.\n", id, connector.Name, op.Mode)
+			if !AwaitUserConfirm() {
+				fmt.Fprintf(cmd.OutOrStdout(), "Quitting.\n")
+				return
 			}
-		} else {
-			fmt.Fprintf(cmd.OutOrStdout(), "Quitting.\n")
-			return
+		}
+
+		for _, connector := range SortedConnectors(connectors) {
+			results = append(results, ExecuteOp(client, op, connector))
 		}
 
 	} else {
-		for _, connectorIdSelected := range connectorIdsSelected {
-			if connectorSelected, ok := connectors[connectorIdSelected]; !ok {
-				fmt.Fprintf(cmd.OutOrStdout(), "ERROR. connectorId: [%d] not found in connectors. Skipping.\n", connectorIdSelected)
+		for _, selected := range connectorIdsSelected {
+			if connectorSelected, ok := connectors[selected.ConnectorId]; !ok {
+				result := OperationResult{ConnectorId: selected.ConnectorId, TaskId: selected.TaskId, Operation: op.Mode}
+				result.complete(nil, fmt.Errorf("connectorId [%d] not found in connectors", selected.ConnectorId))
+				results = append(results, result)
+			} else if selected.TaskId == -1 {
+				results = append(results, ExecuteOp(client, op, connectorSelected))
 			} else {
-				ExecuteOp(op, host, port, connectorSelected)
-				fmt.Fprintf(cmd.OutOrStdout(), "Connector %d %s %sd.\n", connectorSelected.Id, connectorSelected.Name, op.Mode)
+				results = append(results, ExecuteTaskOp(client, op, connectorSelected, selected.TaskId))
 			}
 		}
 	}
+
+	cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OperationResultTemplate", results))
+
+	reached := true
+	if wait && op != Delete {
+		reached = waitAndReport(cmd, client, results.SucceededConnectorNames(), targetState(op))
+	}
+	if results.Failed() > 0 || !reached {
+		os.Exit(1)
+	}
+}
+
+// selectConnectors returns the connectors and tasks selected by the --all, --ids or --tasks flags
+// or prompts the user to select them when none are set
+func selectConnectors(cmd *cobra.Command, op Operation) (bool, bool, []ConnectorTaskId) {
+	if selectAll {
+		return false, true, nil
+	}
+
+	if selectedIds != "" || selectedTasks != "" {
+		var selected []ConnectorTaskId
+		if selectedIds != "" {
+			connectorIds, err := ParseConnectorIds(selectedIds)
+			cobra.CheckErr(err)
+			for _, connectorId := range connectorIds {
+				selected = append(selected, ConnectorTaskId{connectorId, -1})
+			}
+		}
+		if selectedTasks != "" {
+			connectorTaskIds, err := ParseConnectorTaskIds(selectedTasks)
+			cobra.CheckErr(err)
+			selected = append(selected, connectorTaskIds...)
+		}
+		return false, false, selected
+	}
+
+	if !IsInteractive() {
+		cobra.CheckErr(fmt.Errorf("stdin is not a terminal, use --ids or --all to select the connectors to %s", op.Mode))
+	}
+
+	if op == Restart {
+		fmt.Fprintf(cmd.OutOrStdout(), "Enter a connectorId to %s it e.g 4, a connectorId.taskId to %s a task e.g 4.1, enter all to %s all LISTED connectors or q to quit:\n", op.Mode, op.Mode, op.Mode)
+		quit, opAll, selected, err := AwaitConnectorTaskInput(cmd.InOrStdin())
+		cobra.CheckErr(err)
+		return quit, opAll, selected
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Enter a connectorId to %s it e.g 4, enter all to %s all LISTED connectors or q to quit:\n", op.Mode, op.Mode)
+	quit, opAll, connectorIds, err := AwaitConnectorInput(cmd.InOrStdin())
+	cobra.CheckErr(err)
+	var selected []ConnectorTaskId
+	for _, connectorId := range connectorIds {
+		selected = append(selected, ConnectorTaskId{connectorId, -1})
+	}
+	return quit, opAll, selected
+}
+
+// OperationResult is the outcome of an operation on a connector, or on one of its tasks when TaskId is not -1.
+// Status and StatusCode are from the last response received, they are empty when no request was made.
+type OperationResult struct {
+	ConnectorId   int               `json:"connector_id"`
+	ConnectorName string            `json:"connector_name"`
+	TaskId        int               `json:"task_id"`
+	Operation     string            `json:"operation"`
+	Status        string            `json:"status,omitempty"`
+	StatusCode    int               `json:"status_code,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Details       *ConnectorDetails `json:"details,omitempty"`
+}
+
+type OperationResults []OperationResult
+
+func newOperationResult(op Operation, connector Connector, taskId int) OperationResult {
+	return OperationResult{ConnectorId: connector.Id, ConnectorName: connector.Name, TaskId: taskId, Operation: op.Mode}
+}
+
+// complete records the response and error of a request made for the operation
+func (r *OperationResult) complete(resp *connect.Response, err error) {
+	if resp != nil {
+		r.Status = resp.Status
+		r.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+func (r OperationResult) Succeeded() bool {
+	return r.Error == ""
+}
+
+// Target is the connectorId or connectorId.taskId operated on, it is empty for connectors without an id
+func (r OperationResult) Target() string {
+	switch {
+	case r.ConnectorId < 0:
+		return ""
+	case r.TaskId >= 0:
+		return fmt.Sprintf("%d.%d", r.ConnectorId, r.TaskId)
+	}
+	return strconv.Itoa(r.ConnectorId)
+}
+
+func (r OperationResult) FormattedStatus() string {
+	switch {
+	case r.StatusCode != 0:
+		return FormatStatus(r.Status, r.StatusCode)
+	case !r.Succeeded():
+		return Red + "ERROR" + Reset
+	}
+	// nothing needed doing e.g. there were no failed tasks to restart
+	return Gray + "SKIPPED" + Reset
+}
+
+func (results OperationResults) Succeeded() int {
+	count := 0
+	for _, r := range results {
+		if r.Succeeded() {
+			count++
+		}
+	}
+	return count
+}
+
+func (results OperationResults) Failed() int {
+	return len(results) - results.Succeeded()
 }
 
-func ExecuteOp(op Operation, host string, port string, connector Connector) {
+// SucceededConnectorNames returns the names of the connectors that were successfully operated on,
+// or that had a task operated on, in the order they were operated on
+func (results OperationResults) SucceededConnectorNames() []string {
+	var names []string
+	for _, r := range results {
+		if r.Succeeded() && !contains(names, r.ConnectorName) {
+			names = append(names, r.ConnectorName)
+		}
+	}
+	return names
+}
+
+// ExecuteTaskOp operates on a single task of a connector, Kafka Connect only supports restarting individual tasks
+func ExecuteTaskOp(client *connect.Client, op Operation, connector Connector, taskId int) OperationResult {
+	result := newOperationResult(op, connector, taskId)
+
+	if op != Restart {
+		result.complete(nil, fmt.Errorf("Kafka Connect cannot %s individual tasks, only restart them", op.Mode))
+		return result
+	}
+
+	found := false
+	for _, task := range connector.Details.Tasks {
+		if task.Id == taskId {
+			found = true
+		}
+	}
+	if !found {
+		result.complete(nil, fmt.Errorf("taskId [%d] not found in connector", taskId))
+		return result
+	}
+
+	log.Debug(op.Mode, " task ", taskId, " for connector ", connector.Name)
+	result.complete(client.RestartTask(connector.Name, taskId))
+	return result
+}
+
+// ExecuteOp operates on the connector, restarts also restart the tasks selected by the --all-tasks,
+// --failed-tasks and --only-tasks flags. When the worker supports it (Kafka 3.0+) the connector and its
+// tasks are restarted in a single request and the result's Details have their resulting states, with
+// --failed-tasks only the connector and tasks that have FAILED are restarted
+func ExecuteOp(client *connect.Client, op Operation, connector Connector) OperationResult {
+	result := newOperationResult(op, connector, -1)
+
+	if op == Restart && !onlyTasks && (allTasks || failedTasks) && SupportsBulkRestart(client) {
+		status, resp, err := client.RestartConnectorAndTasks(connector.Name, !allTasks)
+		result.complete(resp, err)
+		if err == nil {
+			details := newConnectorDetails(status)
+			result.Details = &details
+		}
+		return result
+	}
 
 	if !onlyTasks {
 		// operate on the connector
-		ExecuteConnectorOp(op, host, port, connector.Name)
+		result.complete(ExecuteConnectorOp(client, op, connector.Name))
+		if !result.Succeeded() {
+			return result
+		}
 	}
 
 	if op == Restart && (onlyTasks || allTasks || failedTasks) {
@@ -170,32 +378,32 @@ func ExecuteOp(op Operation, host string, port string, connector Connector) {
 				continue
 			}
 
-			var emptyBody io.Reader = nil
-			// restart the task
-			opUrl := fmt.Sprintf("http://%s:%s/connectors/%s/tasks/%d/%s", host, port, connector.Name, task.Id, op.Endpoint)
-			log.Debug(op.Mode, " task with URL: ", opUrl)
-
-			req, err := http.NewRequest(op.HttpMethod, opUrl, emptyBody)
-			cobra.CheckErr(err)
-
-			resp, err := http.DefaultClient.Do(req)
-			cobra.CheckErr(err)
-			log.Debug("Got response status: ", resp.StatusCode)
-
+			log.Debug(op.Mode, " task ", task.Id, " for connector ", connector.Name)
+			resp, err := client.RestartTask(connector.Name, task.Id)
+			if err != nil {
+				err = fmt.Errorf("could not restart task %d: %w", task.Id, err)
+			}
+			result.complete(resp, err)
+			if err != nil {
+				return result
+			}
 		}
 	}
+	return result
 }
 
-func ExecuteConnectorOp(op Operation, host string, port string, connectorName string) {
-	var emptyBody io.Reader = nil
-
-	opUrl := fmt.Sprintf("http://%s:%s/connectors/%s/%s", host, port, connectorName, op.Endpoint)
-	log.Debug(op.Mode, " connector with URL: ", opUrl)
-
-	req, err := http.NewRequest(op.HttpMethod, opUrl, emptyBody)
-	cobra.CheckErr(err)
-
-	resp, err := http.DefaultClient.Do(req)
-	cobra.CheckErr(err)
-	log.Debug("Got response status: ", resp.StatusCode)
+func ExecuteConnectorOp(client *connect.Client, op Operation, connectorName string) (*connect.Response, error) {
+	log.Debug(op.Mode, " connector ", connectorName)
+
+	switch op {
+	case Pause:
+		return client.PauseConnector(connectorName)
+	case Resume:
+		return client.ResumeConnector(connectorName)
+	case Delete:
+		return client.DeleteConnector(connectorName)
+	case Restart:
+		return client.RestartConnector(connectorName)
+	}
+	return nil, fmt.Errorf("unknown operation %s", op.Mode)
 }
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jack-tee/conan/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteOpFailure(t *testing.T) {
+	var requests int32
+	// the fake worker returns 404 for pause requests
+	client := fakeConnect(t, []string{"a"}, false, &requests)
+
+	result := ExecuteOp(client, Pause, Connector{Id: 2, Name: "a"})
+	assert.False(t, result.Succeeded())
+	assert.Equal(t, 404, result.StatusCode)
+	assert.Equal(t, "2", result.Target())
+	assert.Equal(t, "pause", result.Operation)
+}
+
+func Test_ExecuteTaskOpOnlyRestarts(t *testing.T) {
+	result := ExecuteTaskOp(nil, Pause, Connector{Id: 2, Name: "a"}, 1)
+	assert.False(t, result.Succeeded())
+	assert.Equal(t, 0, result.StatusCode)
+	assert.Equal(t, "2.1", result.Target())
+}
+
+func Test_OperationResultsCounts(t *testing.T) {
+	results := OperationResults{
+		{ConnectorId: 0, TaskId: -1, Status: "202 Accepted", StatusCode: 202},
+		{ConnectorId: 1, TaskId: -1, Status: "409 Conflict", StatusCode: 409, Error: "conflict"},
+		{ConnectorId: -1, TaskId: -1},
+	}
+	assert.Equal(t, 2, results.Succeeded())
+	assert.Equal(t, 1, results.Failed())
+	assert.Equal(t, "", results[2].Target())
+	assert.Contains(t, results[2].FormattedStatus(), "SKIPPED")
+}
+
+// restartRecorder returns a client for a Kafka 3.6 worker that records the restart requests made
+func restartRecorder(t *testing.T, requests *[]string) *connect.Client {
+	serverInfo = nil
+	t.Cleanup(func() { serverInfo = nil })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `{"version":"3.6.0"}`)
+			return
+		}
+		*requests = append(*requests, r.URL.RequestURI())
+		if r.URL.Query().Get("includeTasks") == "true" {
+			fmt.Fprint(w, `{"name":"a","connector":{"state":"RESTARTING","worker_id":"w1"},"tasks":[{"id":0,"state":"RESTARTING","worker_id":"w1"}]}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return connect.NewClient(server.URL)
+}
+
+func failedTaskConnector() Connector {
+	return Connector{Id: 0, Name: "a", Details: ConnectorDetails{
+		Connector: ConnectorState{State: "RUNNING"},
+		Tasks:     []TaskState{{Id: 0, State: "RUNNING"}, {Id: 1, State: "FAILED"}},
+	}}
+}
+
+func Test_ExecuteOpRestartsFailedTasksInOneRequest(t *testing.T) {
+	defer func(previous bool) { failedTasks = previous }(failedTasks)
+	failedTasks = true
+	var requests []string
+	client := restartRecorder(t, &requests)
+
+	result := ExecuteOp(client, Restart, failedTaskConnector())
+
+	assert.True(t, result.Succeeded())
+	assert.Equal(t, []string{"/connectors/a/restart?includeTasks=true&onlyFailed=true"}, requests)
+	assert.Equal(t, "RESTARTING", result.Details.Tasks[0].State)
+}
+
+func Test_ExecuteOpRestartsFailedTasksIndividuallyOnOldWorkers(t *testing.T) {
+	defer func(previous bool) { failedTasks = previous }(failedTasks)
+	failedTasks = true
+	var requests []string
+	client := restartRecorder(t, &requests)
+	serverInfo = &connect.ServerInfo{Version: "2.8.1"}
+
+	result := ExecuteOp(client, Restart, failedTaskConnector())
+
+	assert.True(t, result.Succeeded())
+	assert.Equal(t, []string{"/connectors/a/restart", "/connectors/a/tasks/1/restart"}, requests)
+	assert.Nil(t, result.Details)
+}
+
+func Test_ExecuteOpRestartsAllTasksInOneRequest(t *testing.T) {
+	allTasks = true
+	defer func() { allTasks = false }()
+	var requests []string
+	client := restartRecorder(t, &requests)
+
+	result := ExecuteOp(client, Restart, Connector{Id: 0, Name: "a"})
+
+	assert.True(t, result.Succeeded())
+	assert.Equal(t, []string{"/connectors/a/restart?includeTasks=true&onlyFailed=false"}, requests)
+	assert.Equal(t, "RESTARTING", result.Details.Tasks[0].State)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var valuesFiles []string
+
+// values are the merged values files, they are only read once
+var values map[string]interface{}
+
+// templateExtension is the extension of connector config files that are rendered before they are read
+const templateExtension = ".tmpl"
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Show the connectors defined by connector config files",
+	Long: `Show the connectors defined by connector config files as they would be loaded,
+after rendering .tmpl files with the --values, merging the --overlay directories and resolving placeholders.
+Secret values are hidden.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No args provided. Please provide paths to the configuration files to render e.g > conan render /conf/conf.json.tmpl --values values.yaml\n")
+			return
+		}
+
+		files := ReadConfigFiles(args)
+
+		if len(files) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No configuration files found for provided paths.\n")
+			return
+		}
+
+		ok := true
+		connectors := make([]ConnectorDefinition, 0, len(files))
+		for _, file := range files {
+			if file.Error != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "ERROR. Could not read %s: %s\n", file.FileName, file.Error)
+				ok = false
+				continue
+			}
+			connectors = append(connectors, newConnectorDefinition(file))
+		}
+
+		if outputFormat == "" || outputFormat == "text" {
+			outputFormat = "json"
+		}
+		cobra.CheckErr(render(cmd.OutOrStdout(), "", connectors))
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+// ConnectorDefinition is a connector in the format of a connector config file
+type ConnectorDefinition struct {
+	Name   string            `json:"name"`
+	State  string            `json:"state,omitempty"`
+	Config map[string]string `json:"config"`
+}
+
+func newConnectorDefinition(file ConfigFile) ConnectorDefinition {
+	return ConnectorDefinition{Name: file.ConnectorName, State: file.State, Config: cleanseConfig(file.Config, file.InterpolatedKeys)}
+}
+
+func isTemplate(fileName string) bool {
+	return strings.HasSuffix(fileName, templateExtension)
+}
+
+// renderTemplateFile renders a .tmpl connector config file with the values
+func renderTemplateFile(fileName string) ([]byte, error) {
+	if values == nil {
+		var err error
+		if values, err = readValues(valuesFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	text, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(fileName).Funcs(template.FuncMap{"json": toJson}).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, values); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// readValues merges the values files, the top level keys of later files override those of earlier ones
+func readValues(fileNames []string) (map[string]interface{}, error) {
+	merged := make(map[string]interface{})
+	for _, fileName := range fileNames {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		var fileValues map[string]interface{}
+		if err := yaml.Unmarshal(b, &fileValues); err != nil {
+			return nil, fmt.Errorf("could not read values %s: %w", fileName, err)
+		}
+		for k, v := range fileValues {
+			merged[k] = v
+		}
+	}
+	return merged, nil
+}
+
+// toJson is a template func that writes a value as json e.g {{ json .topic }} writes a quoted string
+func toJson(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+	addConfigFileFlags(renderCmd)
+	renderCmd.Flags().StringVarP(&outputFormat, "output", "o", "json", "the output format, json or yaml")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jdbcTemplate = `[
+{{- range $i, $table := .tables }}{{ if $i }},{{ end }}
+  {
+    "name": "{{ $.db }}-{{ $table }}",
+    "config": {
+      "connector.class": "io.confluent.connect.jdbc.JdbcSourceConnector",
+      "table.whitelist": {{ json $table }},
+      "poll.interval.ms": {{ $.poll_interval_ms }}
+    }
+  }
+{{- end }}
+]`
+
+func Test_ReadConfigFileTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "db1.json.tmpl", jdbcTemplate)
+	valuesFiles = []string{
+		writeConfigFile(t, dir, "values.yaml", "db: db1\ntables: [table1, table2, table3]\npoll_interval_ms: 1000\n"),
+		writeConfigFile(t, dir, "prod.yaml", "poll_interval_ms: 60000\n"),
+	}
+	values = nil
+	defer func() { valuesFiles, values = nil, nil }()
+
+	files := ReadConfigFile(path)
+	assert.Len(t, files, 3)
+	for i, name := range []string{"db1-table1", "db1-table2", "db1-table3"} {
+		assert.NoError(t, files[i].Error)
+		assert.Equal(t, name, files[i].ConnectorName)
+		assert.Equal(t, "60000", files[i].Config["poll.interval.ms"])
+	}
+	assert.Equal(t, "table2", files[1].Config["table.whitelist"])
+}
+
+func Test_ReadConfigFileTemplateMissingValue(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "a.yaml.tmpl", "name: a\nconnector.class: {{ .class }}\n")
+	values = nil
+	defer func() { values = nil }()
+
+	files := ReadConfigFile(path)
+	assert.Error(t, files[0].Error)
+}
+
+func Test_NewConnectorDefinitionHidesSecrets(t *testing.T) {
+	rendered := newConnectorDefinition(ConfigFile{ConnectorName: "a", Config: map[string]string{"connection.password": "s3cret", "tasks.max": "1"}})
+	assert.Equal(t, map[string]string{"connection.password": "***hidden***", "tasks.max": "1"}, rendered.Config)
+}
@@ -24,9 +24,11 @@ import (
 
 var host string
 var port string
+var scheme string
 var debug bool
 var templates *template.Template
 var templatesPath string
+var concurrency int
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -46,17 +48,27 @@ func Execute() {
 
 func init() {
 	//fmt.Println("Running root.go init")
-	//cobra.OnInitialize(initConfig)
+	// set here as initConfig refers to rootCmd, commands that handle config errors
+	// differently, like diff, set their own PersistentPreRun
+	rootCmd.PersistentPreRun = initConfig
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	//rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.conan.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.conan.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&cluster, "cluster", "c", "", "the cluster from the config file to use, can also be set with CONAN_CLUSTER")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug mode")
 	rootCmd.PersistentFlags().StringVarP(&host, "host", "H", "localhost", "the Kafka Connect rest api host")
 	rootCmd.PersistentFlags().StringVarP(&port, "port", "p", "8083", "the Kafka Connect rest api port")
+	rootCmd.PersistentFlags().StringVar(&scheme, "scheme", "http", "the Kafka Connect rest api scheme, http or https")
+	rootCmd.PersistentFlags().StringVar(&caCert, "ca-cert", "", "a PEM CA bundle used to verify the Kafka Connect rest api certificate")
+	rootCmd.PersistentFlags().StringVar(&clientCert, "client-cert", "", "a PEM client certificate for mTLS")
+	rootCmd.PersistentFlags().StringVar(&clientKey, "client-key", "", "a PEM client key for mTLS")
+	rootCmd.PersistentFlags().StringVar(&basicAuthFile, "basic-auth-file", "", "a file containing username:password for basic auth, otherwise CONAN_USERNAME and CONAN_PASSWORD are used")
+	rootCmd.PersistentFlags().StringVar(&tokenFile, "token-file", "", "a file containing a bearer token, otherwise CONAN_TOKEN is used")
 	rootCmd.PersistentFlags().StringVar(&templatesPath, "templatesPath", "templates/*.tmpl", "path to output templates")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 8, "the maximum number of concurrent requests made to the Kafka Connect rest api")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -82,6 +94,10 @@ func toggleDebug(cmd *cobra.Command, args []string) {
 		"Gray": func(t string) string {
 			return Gray + t + Reset
 		},
+		"FormatState": FormatState,
+		"ConnectorRows": func(connectors map[int]Connector) ConnectorRows {
+			return ConnectorRows{Connectors: connectors}
+		},
 	}
 
 	templates = template.Must(template.New("").Funcs(funcs).Parse(defaultTemplates))
@@ -90,27 +106,3 @@ func toggleDebug(cmd *cobra.Command, args []string) {
 
 	log.Debug("Templates loaded.", templates.DefinedTemplates())
 }
-
-// initConfig reads in config file and ENV variables if set.
-// func initConfig() {
-// 	if cfgFile != "" {
-// 		// Use config file from the flag.
-// 		viper.SetConfigFile(cfgFile)
-// 	} else {
-// 		// Find home directory.
-// 		home, err := os.UserHomeDir()
-// 		cobra.CheckErr(err)
-
-// 		// Search config in home directory with name ".conan" (without extension).
-// 		viper.AddConfigPath(home)
-// 		viper.SetConfigType("yaml")
-// 		viper.SetConfigName(".conan")
-// 	}
-
-// 	viper.AutomaticEnv() // read in environment variables that match
-
-// 	// If a config file is found, read it in.
-// 	if err := viper.ReadInConfig(); err == nil {
-// 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
-// 	}
-// }
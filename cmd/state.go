@@ -17,41 +17,95 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
 )
 
+var stateFormat string
+var stateNameFilter string
+
 // stateCmd represents the state command
 var stateCmd = &cobra.Command{
-	Use:    "state",
+	Use:    "state [filter]",
 	Short:  "List the current state of each connector",
 	Long:   `List the current state of each connector`,
 	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
-		listState(cmd, cmd.OutOrStdout(), args)
+		filter := ""
+		if len(args) > 0 {
+			filter = args[0]
+		}
+		cobra.CheckErr(render(cmd.OutOrStdout(), "StateListTemplate", getConnectorStates(cmd, filter)))
 	},
 }
 
-func listState(cmd *cobra.Command, output io.Writer, args []string) {
-	host, port = GetPersistentFlags(cmd)
-	connectors := GetConnectorsMap(host, port)
-	connectors = GetConnectorsDetails(host, port, connectors)
-	templates.ExecuteTemplate(output, "StateListTemplate", connectors)
+// StateFile is the json format of a state file, it includes the state of each task
+type StateFile struct {
+	CreatedAt  time.Time             `json:"created_at"`
+	Connectors []SavedConnectorState `json:"connectors"`
+}
+
+type SavedConnectorState struct {
+	Name     string           `json:"name"`
+	State    string           `json:"state"`
+	WorkerId string           `json:"worker_id"`
+	Tasks    []SavedTaskState `json:"tasks"`
+}
+
+type SavedTaskState struct {
+	Id       int    `json:"id"`
+	State    string `json:"state"`
+	WorkerId string `json:"worker_id"`
+}
+
+// getConnectorStates gets the status of the connectors whose name contains the filter
+func getConnectorStates(cmd *cobra.Command, filter string) map[int]Connector {
+	client := GetClient(cmd)
+	connectors, err := GetConnectorsWithStatus(client, filter)
+	cobra.CheckErr(err)
+	return connectors
+}
+
+// NewStateFile records the state of the connectors and their tasks ordered by connectorId
+func NewStateFile(connectors map[int]Connector) StateFile {
+	stateFile := StateFile{CreatedAt: time.Now().UTC(), Connectors: make([]SavedConnectorState, 0, len(connectors))}
+	for _, c := range SortedConnectors(connectors) {
+		saved := SavedConnectorState{Name: c.Name, State: c.Details.Connector.State, WorkerId: c.Details.Connector.WorkerId, Tasks: make([]SavedTaskState, 0, len(c.Details.Tasks))}
+		for _, t := range c.Details.Tasks {
+			saved.Tasks = append(saved.Tasks, SavedTaskState{t.Id, t.State, t.WorkerId})
+		}
+		stateFile.Connectors = append(stateFile.Connectors, saved)
+	}
+	return stateFile
+}
+
+// checkStateFormat checks the --format is text or json
+func checkStateFormat() {
+	if stateFormat != "text" && stateFormat != "json" {
+		cobra.CheckErr(fmt.Errorf("unknown state file format [%s] expected text or json", stateFormat))
+	}
 }
 
 var saveCmd = &cobra.Command{
-	Use:    "save",
+	Use:    "save [file]",
 	Short:  "save connector state to a file",
-	Long:   `save connector state to a file`,
+	Long:   `save the state of the connectors, or with --filter of those whose name contains it, to a file`,
+	Args:   cobra.MaximumNArgs(1),
 	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
+		checkStateFormat()
+
 		t := time.Now().UTC()
 		filename := ""
 
@@ -59,7 +113,12 @@ var saveCmd = &cobra.Command{
 			filename = args[0]
 		} else {
 			filename = fmt.Sprintf("./conan-state-%s", t.Format(time.RFC3339))
+			if stateFormat == "json" {
+				filename += ".json"
+			}
 		}
+		connectors := getConnectorStates(cmd, stateNameFilter)
+
 		log.Debug(fmt.Sprintf("creating file %s", filename))
 
 		f, err := os.Create(filename)
@@ -67,80 +126,237 @@ var saveCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 		defer f.Close()
-		listState(cmd, f, args)
+
+		if stateFormat == "json" {
+			cobra.CheckErr(writeJson(f, NewStateFile(connectors)))
+		} else {
+			cobra.CheckErr(render(f, "StateListTemplate", connectors))
+		}
 	},
 }
 
+var stateDryRun bool
+
+// StatePlan is the changes state set will make to the state of the connectors
+type StatePlan struct {
+	Changes []StateChange  `json:"changes"`
+	Skipped []SkippedState `json:"skipped"`
+	Missing []string       `json:"missing"`
+}
+
+// SkippedState is a connector in the state file whose state will not be changed
+type SkippedState struct {
+	StateChange
+	Reason string `json:"reason"`
+}
+
 // setCmd represents the set command
 var setCmd = &cobra.Command{
-	Use:    "set",
+	Use:    "set <file>",
 	Short:  "set connector state based on an input file",
-	Long:   `set connector state based on an input file`,
+	Long:   `set the state of the connectors in an input file, or with --filter of those whose name contains it`,
+	Args:   cobra.ExactArgs(1),
 	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if len(args) > 0 {
-			host, port = GetPersistentFlags(cmd)
-			connectors := GetConnectorsMap(host, port)
-			connectors = GetConnectorsDetails(host, port, connectors)
+			client := GetClient(cmd)
+			connectors, err := GetConnectorsWithStatus(client, "")
+			cobra.CheckErr(err)
 
-			connectorStateMap := make(map[string]string)
+			desired, err := ReadStateChanges(args[0])
+			cobra.CheckErr(err)
 
-			for _, connector := range connectors {
-				connectorStateMap[connector.Name] = connector.Details.Connector.State
+			if stateNameFilter != "" {
+				desired = filterStateChanges(desired, stateNameFilter)
 			}
-			log.Debug("existing connector state", connectorStateMap)
 
-			f, err := os.Open(args[0])
-			if err != nil {
-				log.Fatal(err)
+			plan := PlanStateChanges(connectors, desired)
+			cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "StatePlanTemplate", plan))
+
+			if len(plan.Changes) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Nothing to set.\n")
+				return
 			}
-			defer f.Close()
-			scanner := bufio.NewScanner(f)
-			line := 1
-
-			for scanner.Scan() {
-
-				t := strings.TrimSpace(scanner.Text())
-
-				if len(t) > 0 {
-					connStatus := strings.Split(t, ",")
-
-					if len(connStatus) == 2 {
-
-						if existingState, ok := connectorStateMap[connStatus[0]]; ok {
-							if existingState != "RUNNING" && existingState != "PAUSED" {
-								log.Debug(fmt.Sprintf("skipping connector %s as existing state is %s", string(connStatus[0]), existingState))
-							} else if existingState == connStatus[1] {
-								log.Debug(fmt.Sprintf("skipping connector %s as already in desired state %s", string(connStatus[0]), existingState))
-							} else {
-								switch connStatus[1] {
-								case "PAUSED":
-									ExecuteConnectorOp(Pause, host, port, string(connStatus[0]))
-									log.Info(fmt.Sprintf("setting connector state for %s to PAUSED\n", string(connStatus[0])))
-								case "RUNNING":
-									ExecuteConnectorOp(Resume, host, port, string(connStatus[0]))
-									log.Info(fmt.Sprintf("setting connector state for %s to RUNNING\n", string(connStatus[0])))
-								default:
-									log.Warn(fmt.Sprintf("skipping connector state for %s because desired state is %s existing state is %s", string(connStatus[0]), string(connStatus[1]), existingState))
-								}
-							}
-						}
-					} else {
-						log.Warn(fmt.Sprintf("line %d in state file could not be parsed [%s] expected [{connectorName},{state}]\n", line, t))
-					}
+			if stateDryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "Dry run, skipped setting connector state.\n")
+				return
+			}
+
+			if !assumeYes {
+				if !IsInteractive() {
+					cobra.CheckErr(fmt.Errorf("stdin is not a terminal so cannot confirm, use --yes to set the connector state"))
 				}
-				line += 1
+				fmt.Fprintf(cmd.OutOrStdout(), "Set the above connector states? y/N ")
+				if !AwaitUserConfirm() {
+					fmt.Fprintf(cmd.OutOrStdout(), "Skipped setting connector state.\n")
+					return
+				}
+			}
+
+			results := SetStates(client, plan)
+			cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "OperationResultTemplate", results))
+			if results.Failed() > 0 {
+				os.Exit(1)
 			}
 		}
 
 	},
 }
 
+// ReadStateChanges reads the desired state of each connector from a json or text state file
+func ReadStateChanges(filename string) ([]StateChange, error) {
+	isJson, err := isJsonStateFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isJson {
+		return ReadJsonStateFile(filename)
+	}
+	return ReadStateFile(filename)
+}
+
+// isJsonStateFile reports whether the state file was saved with --format json,
+// either from its .json extension or its content starting with {
+func isJsonStateFile(filename string) (bool, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return true, nil
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")), nil
+}
+
+// ReadStateFile reads the desired state of each connector from a file of {connectorName},{state} lines
+func ReadStateFile(filename string) ([]StateChange, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var desired []StateChange
+	scanner := bufio.NewScanner(f)
+	line := 1
+
+	for scanner.Scan() {
+
+		t := strings.TrimSpace(scanner.Text())
+
+		if len(t) > 0 {
+			connStatus := strings.Split(t, ",")
+
+			if len(connStatus) == 2 && strings.TrimSpace(connStatus[0]) != "" && strings.TrimSpace(connStatus[1]) != "" {
+				desired = append(desired, StateChange{ConnectorName: strings.TrimSpace(connStatus[0]), To: strings.TrimSpace(connStatus[1])})
+			} else {
+				log.Warn(fmt.Sprintf("line %d in state file could not be parsed [%s] expected [{connectorName},{state}]\n", line, t))
+			}
+		}
+		line += 1
+	}
+	return desired, scanner.Err()
+}
+
+// ReadJsonStateFile reads the desired state of each connector from a json state file
+func ReadJsonStateFile(filename string) ([]StateChange, error) {
+	stateFile, err := readJsonStateFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	desired := make([]StateChange, 0, len(stateFile.Connectors))
+	for _, c := range stateFile.Connectors {
+		desired = append(desired, StateChange{ConnectorName: c.Name, To: c.State})
+	}
+	return desired, nil
+}
+
+func readJsonStateFile(filename string) (StateFile, error) {
+	var stateFile StateFile
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return stateFile, err
+	}
+	if err := json.Unmarshal(b, &stateFile); err != nil {
+		return stateFile, fmt.Errorf("could not read json state file %s: %w", filename, err)
+	}
+	return stateFile, nil
+}
+
+// filterStateChanges returns the state changes for connectors whose name contains the filter
+func filterStateChanges(changes []StateChange, filter string) []StateChange {
+	var filtered []StateChange
+	for _, change := range changes {
+		if matchesName(change.ConnectorName, filter) {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// PlanStateChanges compares the desired states with the existing state of the connectors. Only RUNNING
+// and PAUSED connectors can be paused and resumed, other connectors and those already in their desired
+// state are skipped
+func PlanStateChanges(connectors map[int]Connector, desired []StateChange) StatePlan {
+	plan := StatePlan{Changes: []StateChange{}, Skipped: []SkippedState{}, Missing: []string{}}
+
+	connectorStateMap := make(map[string]string)
+	for _, connector := range connectors {
+		connectorStateMap[connector.Name] = connector.Details.Connector.State
+	}
+	log.Debug("existing connector state", connectorStateMap)
+
+	for _, d := range desired {
+		existingState, ok := connectorStateMap[d.ConnectorName]
+		if !ok {
+			plan.Missing = append(plan.Missing, d.ConnectorName)
+			continue
+		}
+		change := StateChange{ConnectorName: d.ConnectorName, From: existingState, To: d.To}
+
+		switch {
+		case existingState != "RUNNING" && existingState != "PAUSED":
+			plan.Skipped = append(plan.Skipped, SkippedState{change, fmt.Sprintf("existing state is %s", existingState)})
+		case existingState == d.To:
+			plan.Skipped = append(plan.Skipped, SkippedState{change, fmt.Sprintf("already in desired state %s", existingState)})
+		case d.To != "RUNNING" && d.To != "PAUSED":
+			plan.Skipped = append(plan.Skipped, SkippedState{change, fmt.Sprintf("desired state is %s, expected RUNNING or PAUSED", d.To)})
+		default:
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+	return plan
+}
+
+// SetStates pauses or resumes the connectors to make the planned changes
+func SetStates(client *connect.Client, plan StatePlan) OperationResults {
+	var results OperationResults
+	for _, change := range plan.Changes {
+		op := Resume
+		if change.To == "PAUSED" {
+			op = Pause
+		}
+		log.Debug(fmt.Sprintf("setting connector state for %s to %s", change.ConnectorName, change.To))
+		// the connectors are not listed so have no connectorId
+		result := OperationResult{ConnectorId: -1, ConnectorName: change.ConnectorName, TaskId: -1, Operation: op.Mode}
+		result.complete(ExecuteConnectorOp(client, op, change.ConnectorName))
+		results = append(results, result)
+	}
+	return results
+}
+
 func init() {
 	rootCmd.AddCommand(stateCmd)
+	stateCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "the output format, one of text, json or yaml")
 	stateCmd.AddCommand(saveCmd)
 	stateCmd.AddCommand(setCmd)
+	saveCmd.Flags().StringVar(&stateFormat, "format", "text", "the state file format, text for {connectorName},{state} lines or json which includes the state of each task")
+	for _, c := range []*cobra.Command{saveCmd, setCmd} {
+		c.Flags().StringVarP(&stateNameFilter, "filter", "f", "", "only the connectors whose name contains the filter")
+	}
+	setCmd.Flags().BoolVar(&stateDryRun, "dry-run", false, "only show the changes that would be made")
+	setCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not prompt for confirmation")
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
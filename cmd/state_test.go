@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadStateFile(t *testing.T) {
+	filename := writeConfigFile(t, t.TempDir(), "state", "a,PAUSED\n\nnot a state line\n\"name\": \"c\",\n,RUNNING\nb,RUNNING\n")
+
+	desired, err := ReadStateFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, []StateChange{{ConnectorName: "a", To: "PAUSED"}, {ConnectorName: "b", To: "RUNNING"}}, desired)
+}
+
+func Test_PlanStateChanges(t *testing.T) {
+	connectors := map[int]Connector{}
+	for i, state := range []string{"RUNNING", "PAUSED", "FAILED", "UNASSIGNED", "RUNNING"} {
+		name := string(rune('a' + i))
+		connectors[i] = Connector{Id: i, Name: name, Details: ConnectorDetails{Connector: ConnectorState{State: state}}}
+	}
+
+	plan := PlanStateChanges(connectors, []StateChange{
+		{ConnectorName: "a", To: "PAUSED"},
+		{ConnectorName: "b", To: "PAUSED"},
+		{ConnectorName: "c", To: "RUNNING"},
+		{ConnectorName: "d", To: "RUNNING"},
+		{ConnectorName: "e", To: "STOPPED"},
+		{ConnectorName: "deleted", To: "RUNNING"},
+	})
+
+	assert.Equal(t, []StateChange{{ConnectorName: "a", From: "RUNNING", To: "PAUSED"}}, plan.Changes)
+	assert.Equal(t, []SkippedState{
+		{StateChange{ConnectorName: "b", From: "PAUSED", To: "PAUSED"}, "already in desired state PAUSED"},
+		{StateChange{ConnectorName: "c", From: "FAILED", To: "RUNNING"}, "existing state is FAILED"},
+		{StateChange{ConnectorName: "d", From: "UNASSIGNED", To: "RUNNING"}, "existing state is UNASSIGNED"},
+		{StateChange{ConnectorName: "e", From: "RUNNING", To: "STOPPED"}, "desired state is STOPPED, expected RUNNING or PAUSED"},
+	}, plan.Skipped)
+	assert.Equal(t, []string{"deleted"}, plan.Missing)
+}
+
+func Test_NewStateFile(t *testing.T) {
+	connectors := map[int]Connector{
+		1: {Id: 1, Name: "b", Details: ConnectorDetails{Connector: ConnectorState{State: "PAUSED", WorkerId: "w1"}}},
+		0: {Id: 0, Name: "a", Details: ConnectorDetails{
+			Connector: ConnectorState{State: "RUNNING", WorkerId: "w1"},
+			Tasks:     []TaskState{{Id: 0, State: "RUNNING", WorkerId: "w2"}, {Id: 1, State: "FAILED", WorkerId: "w1", Trace: "boom"}},
+		}},
+	}
+
+	stateFile := NewStateFile(connectors)
+	assert.Equal(t, []SavedConnectorState{
+		{Name: "a", State: "RUNNING", WorkerId: "w1", Tasks: []SavedTaskState{{0, "RUNNING", "w2"}, {1, "FAILED", "w1"}}},
+		{Name: "b", State: "PAUSED", WorkerId: "w1", Tasks: []SavedTaskState{}},
+	}, stateFile.Connectors)
+
+	var b bytes.Buffer
+	assert.NoError(t, writeJson(&b, stateFile))
+	filename := writeConfigFile(t, t.TempDir(), "state.json", b.String())
+
+	desired, err := ReadJsonStateFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, []StateChange{{ConnectorName: "a", To: "RUNNING"}, {ConnectorName: "b", To: "PAUSED"}}, desired)
+	assert.Equal(t, []StateChange{{ConnectorName: "b", To: "PAUSED"}}, filterStateChanges(desired, "b"))
+}
+
+func Test_ReadJsonStateFileInvalid(t *testing.T) {
+	filename := writeConfigFile(t, t.TempDir(), "state", "a,PAUSED\n")
+
+	_, err := ReadJsonStateFile(filename)
+	assert.Error(t, err)
+}
+
+func Test_ReadStateChangesDetectsJson(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"created_at": "2024-05-01T09:28:36Z", "connectors": [{"name": "a", "state": "PAUSED", "tasks": []}]}`
+
+	// json state files are detected from their extension or their content
+	for _, name := range []string{"state.json", "state"} {
+		desired, err := ReadStateChanges(writeConfigFile(t, dir, name, content))
+		assert.NoError(t, err)
+		assert.Equal(t, []StateChange{{ConnectorName: "a", To: "PAUSED"}}, desired)
+	}
+
+	desired, err := ReadStateChanges(writeConfigFile(t, dir, "state.txt", "a,RUNNING\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []StateChange{{ConnectorName: "a", To: "RUNNING"}}, desired)
+}
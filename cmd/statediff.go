@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// StateDiff is the difference in the state of the connectors between two state files
+type StateDiff struct {
+	From         string                 `json:"from"`
+	To           string                 `json:"to"`
+	Added        []SavedConnectorState  `json:"added"`
+	Removed      []SavedConnectorState  `json:"removed"`
+	Changed      []ConnectorStateChange `json:"changed"`
+	ChangedTasks []TaskStateChange      `json:"changed_tasks"`
+	Unchanged    int                    `json:"unchanged"`
+}
+
+// ConnectorStateChange is a connector whose state differs between the state files
+type ConnectorStateChange struct {
+	ConnectorName string `json:"name"`
+	Change        string `json:"change"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+}
+
+// TaskStateChange is a task whose state or worker differs between the state files
+type TaskStateChange struct {
+	ConnectorName string `json:"name"`
+	TaskId        int    `json:"task_id"`
+	FromState     string `json:"from_state"`
+	ToState       string `json:"to_state"`
+	FromWorkerId  string `json:"from_worker_id"`
+	ToWorkerId    string `json:"to_worker_id"`
+}
+
+func (t TaskStateChange) StateChanged() bool {
+	return t.FromState != t.ToState
+}
+
+func (t TaskStateChange) Moved() bool {
+	return t.FromWorkerId != t.ToWorkerId
+}
+
+var stateDiffCmd = &cobra.Command{
+	Use:   "diff <fileA> [fileB]",
+	Short: "compare the connector state in two state files",
+	Long: `compare the connector state in two state files, or in a state file and the deployed connectors when fileB is omitted.
+Shows the connectors that were added, removed, paused, resumed or failed. Task states and workers are compared when both
+sides include them, i.e. json state files saved with --format json or the deployed connectors. The format of each file is
+detected from its .json extension or its content.`,
+	Args:   cobra.RangeArgs(1, 2),
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := readStateSnapshot(args[0])
+		cobra.CheckErr(err)
+
+		var to StateFile
+		toName := "deployed"
+		if len(args) > 1 {
+			toName = args[1]
+			to, err = readStateSnapshot(args[1])
+			cobra.CheckErr(err)
+		} else {
+			to = NewStateFile(getConnectorStates(cmd, ""))
+		}
+
+		cobra.CheckErr(render(cmd.OutOrStdout(), "StateDiffTemplate", DiffStates(args[0], from, toName, to)))
+	},
+}
+
+// readStateSnapshot reads a json or text state file, a text state file has no task states
+func readStateSnapshot(filename string) (StateFile, error) {
+	isJson, err := isJsonStateFile(filename)
+	if err != nil {
+		return StateFile{}, err
+	}
+	if isJson {
+		return readJsonStateFile(filename)
+	}
+	states, err := ReadStateFile(filename)
+	if err != nil {
+		return StateFile{}, err
+	}
+	var stateFile StateFile
+	for _, s := range states {
+		stateFile.Connectors = append(stateFile.Connectors, SavedConnectorState{Name: s.ConnectorName, State: s.To})
+	}
+	return stateFile, nil
+}
+
+// DiffStates compares the connectors in two state files, tasks are only compared when
+// both sides include them
+func DiffStates(fromName string, from StateFile, toName string, to StateFile) StateDiff {
+	diff := StateDiff{From: fromName, To: toName, Added: []SavedConnectorState{}, Removed: []SavedConnectorState{},
+		Changed: []ConnectorStateChange{}, ChangedTasks: []TaskStateChange{}}
+
+	fromConnectors := make(map[string]SavedConnectorState)
+	for _, c := range from.Connectors {
+		fromConnectors[c.Name] = c
+	}
+	toConnectors := make(map[string]bool)
+
+	for _, t := range to.Connectors {
+		toConnectors[t.Name] = true
+		f, ok := fromConnectors[t.Name]
+		if !ok {
+			diff.Added = append(diff.Added, t)
+			continue
+		}
+
+		if f.State != t.State {
+			diff.Changed = append(diff.Changed, ConnectorStateChange{t.Name, stateChange(f.State, t.State), f.State, t.State})
+		}
+
+		tasksChanged := false
+		if f.Tasks != nil && t.Tasks != nil {
+			tasksChanged = diffTasks(&diff, f, t)
+		}
+		if f.State == t.State && !tasksChanged {
+			diff.Unchanged += 1
+		}
+	}
+
+	for _, f := range from.Connectors {
+		if !toConnectors[f.Name] {
+			diff.Removed = append(diff.Removed, f)
+		}
+	}
+	return diff
+}
+
+// diffTasks adds the tasks of the connector whose state or worker changed to the diff
+func diffTasks(diff *StateDiff, from SavedConnectorState, to SavedConnectorState) bool {
+	fromTasks := make(map[int]SavedTaskState)
+	for _, task := range from.Tasks {
+		fromTasks[task.Id] = task
+	}
+
+	changed := false
+	for _, task := range to.Tasks {
+		f, ok := fromTasks[task.Id]
+		if !ok {
+			// a task added by a change in tasks.max has no previous state or worker
+			f = SavedTaskState{Id: task.Id}
+		}
+		if f.State != task.State || f.WorkerId != task.WorkerId {
+			diff.ChangedTasks = append(diff.ChangedTasks, TaskStateChange{to.Name, task.Id, f.State, task.State, f.WorkerId, task.WorkerId})
+			changed = true
+		}
+		delete(fromTasks, task.Id)
+	}
+	for _, f := range from.Tasks {
+		if _, ok := fromTasks[f.Id]; ok {
+			diff.ChangedTasks = append(diff.ChangedTasks, TaskStateChange{to.Name, f.Id, f.State, "", f.WorkerId, ""})
+			changed = true
+		}
+	}
+	return changed
+}
+
+// stateChange describes a change in connector state
+func stateChange(from string, to string) string {
+	switch {
+	case to == "PAUSED":
+		return "paused"
+	case to == "FAILED":
+		return "failed"
+	case to == "RUNNING" && from == "PAUSED":
+		return "resumed"
+	case to == "RUNNING" && from == "FAILED":
+		return "recovered"
+	}
+	return fmt.Sprintf("now %s", to)
+}
+
+func init() {
+	stateCmd.AddCommand(stateDiffCmd)
+	stateDiffCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "the output format, one of text, json or yaml")
+}
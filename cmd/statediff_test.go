@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffStates(t *testing.T) {
+	from := StateFile{Connectors: []SavedConnectorState{
+		{Name: "a", State: "RUNNING", Tasks: []SavedTaskState{{0, "RUNNING", "w1"}, {1, "RUNNING", "w2"}}},
+		{Name: "b", State: "PAUSED", Tasks: []SavedTaskState{{0, "PAUSED", "w1"}}},
+		{Name: "c", State: "RUNNING", Tasks: []SavedTaskState{{0, "RUNNING", "w1"}}},
+		{Name: "removed", State: "RUNNING"},
+	}}
+	to := StateFile{Connectors: []SavedConnectorState{
+		{Name: "a", State: "RUNNING", Tasks: []SavedTaskState{{0, "RUNNING", "w2"}, {1, "FAILED", "w2"}}},
+		{Name: "b", State: "RUNNING", Tasks: []SavedTaskState{{0, "RUNNING", "w1"}}},
+		{Name: "c", State: "RUNNING", Tasks: []SavedTaskState{{0, "RUNNING", "w1"}}},
+		{Name: "added", State: "RUNNING"},
+	}}
+
+	diff := DiffStates("from", from, "to", to)
+	assert.Equal(t, []SavedConnectorState{{Name: "added", State: "RUNNING"}}, diff.Added)
+	assert.Equal(t, []SavedConnectorState{{Name: "removed", State: "RUNNING"}}, diff.Removed)
+	assert.Equal(t, []ConnectorStateChange{{"b", "resumed", "PAUSED", "RUNNING"}}, diff.Changed)
+	assert.Equal(t, []TaskStateChange{
+		{"a", 0, "RUNNING", "RUNNING", "w1", "w2"},
+		{"a", 1, "RUNNING", "FAILED", "w2", "w2"},
+		{"b", 0, "PAUSED", "RUNNING", "w1", "w1"},
+	}, diff.ChangedTasks)
+	assert.Equal(t, 1, diff.Unchanged)
+	assert.True(t, diff.ChangedTasks[0].Moved())
+	assert.False(t, diff.ChangedTasks[0].StateChanged())
+}
+
+func Test_DiffStatesWithoutTasks(t *testing.T) {
+	// text state files have no tasks so only the connector states are compared
+	from := StateFile{Connectors: []SavedConnectorState{{Name: "a", State: "RUNNING"}}}
+	to := StateFile{Connectors: []SavedConnectorState{{Name: "a", State: "FAILED", Tasks: []SavedTaskState{{0, "FAILED", "w1"}}}}}
+
+	diff := DiffStates("from", from, "to", to)
+	assert.Equal(t, []ConnectorStateChange{{"a", "failed", "RUNNING", "FAILED"}}, diff.Changed)
+	assert.Empty(t, diff.ChangedTasks)
+}
+
+func Test_StateChange(t *testing.T) {
+	assert.Equal(t, "paused", stateChange("RUNNING", "PAUSED"))
+	assert.Equal(t, "resumed", stateChange("PAUSED", "RUNNING"))
+	assert.Equal(t, "recovered", stateChange("FAILED", "RUNNING"))
+	assert.Equal(t, "now UNASSIGNED", stateChange("RUNNING", "UNASSIGNED"))
+}
+
+func Test_ReadStateSnapshotDetectsFormat(t *testing.T) {
+	dir := t.TempDir()
+	a, err := readStateSnapshot(writeConfigFile(t, dir, "a.json", `{"connectors": [{"name": "a", "state": "RUNNING", "tasks": [{"id": 0, "state": "RUNNING", "worker_id": "w1"}]}]}`))
+	assert.NoError(t, err)
+	b, err := readStateSnapshot(writeConfigFile(t, dir, "b.txt", "a,PAUSED\n"))
+	assert.NoError(t, err)
+
+	// a json file is not read as text lines e.g "name": "a", so the diff only has the real change
+	diff := DiffStates("a.json", a, "b.txt", b)
+	assert.Equal(t, []ConnectorStateChange{{"a", "paused", "RUNNING", "PAUSED"}}, diff.Changed)
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Empty(t, diff.ChangedTasks)
+}
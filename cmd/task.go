@@ -2,17 +2,25 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 type TaskState struct {
-	Id       int
-	State    string
-	WorkerId string `json:"worker_id"`
-	Trace    string
-	Config   map[string]string
+	Id       int               `json:"id"`
+	State    string            `json:"state"`
+	WorkerId string            `json:"worker_id"`
+	Trace    string            `json:"trace,omitempty"`
+	Config   map[string]string `json:"config"`
+}
+
+// MarshalJSON hides secret task config values so they are not written by --output json or yaml
+func (t TaskState) MarshalJSON() ([]byte, error) {
+	type taskState TaskState
+	t.Config = cleanseConfig(t.Config, nil)
+	return json.Marshal(taskState(t))
 }
 
 func (t TaskState) Summary() string {
@@ -28,6 +36,11 @@ func (t TaskState) Summary() string {
 	return output.String()
 }
 
+// ShortTrace is the first line of the trace, the full trace is shown by the trace command
+func (t TaskState) ShortTrace() string {
+	return TruncateTrace(t.Trace)
+}
+
 func (t TaskState) FormattedState() string {
 	return FormatState(t.State)
 }
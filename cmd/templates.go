@@ -3,16 +3,39 @@ package cmd
 const defaultTemplates = `
 {{ define "ListTemplate" -}}
 LIST: {{ len . }} Connectors
-{{ range $id, $connector := . -}}
-    {{ printf "%-3d %-78s" $connector.Id $connector.Name }} {{ printf "%-11s" $connector.Details.Connector.FormattedState }} {{ $connector.PollInterval }}
+{{ template "ConnectorRowsTemplate" (ConnectorRows .) }}
+Total: {{ len . }} Connectors
+{{ end }}
+
+{{ define "ConnectorRowsTemplate" -}}
+{{ range $id, $connector := .Connectors -}}
+    {{ printf "%-3d %-78s" $connector.Id $connector.Name }} {{ printf "%-11s" ($.ConnectorState $connector) }} {{ $connector.PollInterval }}
     {{ range $task := $connector.Details.Tasks -}}
         {{- printf "%3d.%-2d" $connector.Id $task.Id -}} 
         {{ printf "%-75.75s" $task.Summary }}
-        {{- printf " %8s %s  %s"  $task.FormattedState $task.WorkerId $task.Trace }}
+        {{- printf " %8s %s  %s"  ($.TaskState $connector $task) $task.WorkerId $task.ShortTrace }}
     {{ end }}
 {{ end }}
-Total: {{ len . }} Connectors
+{{- end }}
+
+{{ define "WatchTemplate" -}}
+Every {{ .Interval }}s: conan watch {{ .Refreshed.Format "15:04:05" }}
+{{ if .Error }}{{ Red "Error refreshing connectors:" }} {{ .Error }}
+{{ end -}}
+Connectors: {{ range $i, $count := .ConnectorCounts }}{{ if $i }}, {{ end }}{{ $count.FormattedState }} {{ $count.Count }}{{ end }}
+Tasks:      {{ range $i, $count := .TaskCounts }}{{ if $i }}, {{ end }}{{ $count.FormattedState }} {{ $count.Count }}{{ end }}
+
+{{ template "ConnectorRowsTemplate" .ConnectorRows }}
+Total: {{ len .Connectors }} Connectors
+{{ end }}
+
+{{ define "TraceTemplate" -}}
+TRACES: {{ len . }} Failed
+{{ range $failure := . }}
+{{ printf "%-6s %s" $failure.Target $failure.ConnectorName }}{{ if ge $failure.TaskId 0 }} task {{ $failure.TaskId }}{{ end }} {{ Red "FAILED" }} {{ $failure.WorkerId }}
+{{ $failure.Trace }}
 {{ end }}
+{{- end }}
 
 {{ define "StateListTemplate" -}}
 {{ range $id, $connector := . -}}
@@ -20,6 +43,88 @@ Total: {{ len . }} Connectors
 {{ end }}
 {{ end }}
 
+{{ define "TaskStatesTemplate" -}}
+{{ range $task := .Tasks -}}
+    {{ printf "    task %-3d %-20s %s" $task.Id $task.FormattedState $task.WorkerId }}
+{{ end -}}
+{{ end }}
+
+{{ define "OperationResultTemplate" -}}
+RESULTS: {{ len . }} Operations
+{{ range $result := . -}}
+    {{ printf "%-6s %-70s %-8s" $result.Target $result.ConnectorName $result.Operation }} {{ $result.FormattedStatus }}{{ if $result.Error }} {{ $result.Error }}{{ end }}
+{{ if $result.Details }}{{ template "TaskStatesTemplate" $result.Details }}{{ end -}}
+{{ end }}
+Succeeded: {{ .Succeeded }}, Failed: {{ .Failed }}
+{{ end }}
+
+{{ define "WaitResultTemplate" -}}
+WAIT: {{ len . }} Connectors
+{{ range $result := . -}}
+    {{ printf "%-70s %-7s" $result.ConnectorName $result.TargetState }} {{ printf "%-9s" $result.FormattedOutcome }} after {{ $result.FormattedDuration }} {{ $result.Details.Connector.FormattedState }}{{ if $result.Error }} {{ $result.Error }}{{ end }}
+{{ template "TaskStatesTemplate" $result.Details }}
+{{- end }}
+Reached: {{ .Reached }}, Not Reached: {{ .NotReached }}
+{{ end }}
+
+{{ define "StatePlanTemplate" -}}
+State Changes: {{ len .Changes }}
+{{- range $change := .Changes }}
+    {{ $change.ConnectorName }}: {{ FormatState $change.From }} -> {{ FormatState $change.To }}
+{{- end }}
+
+Skipped: {{ len .Skipped }}
+{{- range $skipped := .Skipped }}
+    {{ $skipped.ConnectorName }}: {{ Gray $skipped.Reason }}
+{{- end }}
+
+{{ if .Missing -}}
+Missing Connectors: {{ len .Missing }} (these are in the state file but are not deployed)
+{{- range $name := .Missing }}
+    {{ Yellow $name }}
+{{- end }}
+
+{{ end -}}
+{{ end }}
+
+{{ define "StateDiffTemplate" -}}
+STATE DIFF: {{ .From }} -> {{ .To }}
+
+Added Connectors: {{ len .Added }}
+{{- range $connector := .Added }}
+    {{ Green $connector.Name }} {{ FormatState $connector.State }}
+{{- end }}
+
+Removed Connectors: {{ len .Removed }}
+{{- range $connector := .Removed }}
+    {{ Red $connector.Name }} {{ FormatState $connector.State }}
+{{- end }}
+
+Changed Connectors: {{ len .Changed }}
+{{- range $change := .Changed }}
+    {{ printf "%-70s %-10s" $change.ConnectorName $change.Change }} {{ FormatState $change.From }} -> {{ FormatState $change.To }}
+{{- end }}
+
+Changed Tasks: {{ len .ChangedTasks }}
+{{- range $task := .ChangedTasks }}
+    {{ printf "%-64s task %-3d" $task.ConnectorName $task.TaskId }}
+    {{- if $task.StateChanged }} {{ FormatState (or $task.FromState "new") }} -> {{ FormatState (or $task.ToState "removed") }}{{ end }}
+    {{- if $task.Moved }} moved {{ or $task.FromWorkerId "none" }} -> {{ or $task.ToWorkerId "none" }}{{ end }}
+{{- end }}
+
+Unchanged: {{ .Unchanged }}, Added: {{ len .Added }}, Removed: {{ len .Removed }}, Changed: {{ len .Changed }}, Changed Tasks: {{ len .ChangedTasks }}
+{{ end }}
+
+{{ define "OffsetsTemplate" -}}
+OFFSETS: {{ len . }} Connectors
+{{ range $connector := . -}}
+    {{ printf "%-3d %s" $connector.ConnectorId $connector.ConnectorName }} {{ len $connector.Offsets }} Partitions
+{{- range $offset := $connector.FormattedOffsets }}
+    {{ $offset }}
+{{- end }}
+{{ end }}
+{{- end }}
+
 {{ define "ValidationTemplate" -}}
 VALIDATION: {{ len . }} Connectors
 {{ range $id, $file := . -}}
@@ -40,7 +145,13 @@ VALIDATION: {{ len . }} Connectors
 {{ end }}
 
 {{ define "DiffTemplate" -}}
-{{- if .ShowOmitted -}}
+{{- if .Prune -}}
+Deleted Connectors: {{ len .OmittedConnectors }} (these are connectors that are currently deployed but are not included in the specified config files)
+{{- range $id, $name := .OmittedConnectors }}
+    {{ Red $name }}
+{{- end }}
+
+{{ else if .ShowOmitted -}}
 Omitted Connectors: {{ len .OmittedConnectors }} (these are connectors that are currently deployed but are not included in the specified config files)
 {{- range $id, $name := .OmittedConnectors }}
     {{ $name }}
@@ -77,7 +188,15 @@ Changed Connectors: {{ len .ChangedConnectors }}
       {{ Red (printf "- %s: %s" $key $val) }}
     {{- end }}
 {{ end }}
+{{- if .StateChanges }}
+State Changes: {{ len .StateChanges }}
+{{- range $id, $change := .StateChanges }}
+    {{ $change.ConnectorName }}: {{ or $change.From "new" }} -> {{ FormatState $change.To }}
+{{- end }}
+{{ end }}
 Unchanged: {{ len .UnchangedConnectors }}, New: {{ len .NewConnectors }}, Changed: {{ len .ChangedConnectors }}
+{{- if .StateChanges }}, State Changes: {{ len .StateChanges }}{{ end }}
+{{- if .Prune }}, Deleted: {{ len .OmittedConnectors }}{{ end }}
 {{ end }}
 
 
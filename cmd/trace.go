@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var rootCause bool
+
+// maxTraceLength is how much of a trace is shown in the list output
+const maxTraceLength = 100
+
+// traceCmd represents the trace command
+var traceCmd = &cobra.Command{
+	Use:   "trace [filter]",
+	Short: "Show the stack traces of failed connectors and tasks",
+	Long: `Show the full stack traces of the FAILED connectors and tasks whose connector name contains the filter.
+With --root-cause only the root "Caused by:" line of each trace is shown.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		connectors, err := FilterConnectors(GetClient(cmd), args)
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(render(cmd.OutOrStdout(), "TraceTemplate", FailedTraces(connectors, rootCause)))
+	},
+}
+
+// FailedTrace is the stack trace of a FAILED connector, or of one of its tasks when TaskId is not -1
+type FailedTrace struct {
+	ConnectorId   int    `json:"connector_id"`
+	ConnectorName string `json:"connector_name"`
+	TaskId        int    `json:"task_id"`
+	WorkerId      string `json:"worker_id"`
+	Trace         string `json:"trace"`
+}
+
+// FailedTraces returns the traces of the FAILED connectors and tasks ordered by connectorId then taskId,
+// with rootCause each trace is collapsed to its root cause
+func FailedTraces(connectors map[int]Connector, rootCause bool) []FailedTrace {
+	traces := make([]FailedTrace, 0)
+	add := func(trace FailedTrace) {
+		if rootCause {
+			trace.Trace = RootCause(trace.Trace)
+		}
+		traces = append(traces, trace)
+	}
+
+	for _, c := range SortedConnectors(connectors) {
+		if c.Details.Connector.State == "FAILED" {
+			add(FailedTrace{c.Id, c.Name, -1, c.Details.Connector.WorkerId, c.Details.Connector.Trace})
+		}
+		for _, t := range c.Details.Tasks {
+			if t.State == "FAILED" {
+				add(FailedTrace{c.Id, c.Name, t.Id, t.WorkerId, t.Trace})
+			}
+		}
+	}
+	return traces
+}
+
+// Target is the connectorId or connectorId.taskId that failed
+func (f FailedTrace) Target() string {
+	if f.TaskId >= 0 {
+		return fmt.Sprintf("%d.%d", f.ConnectorId, f.TaskId)
+	}
+	return fmt.Sprintf("%d", f.ConnectorId)
+}
+
+// RootCause returns the last "Caused by:" line of a java stack trace, or its first line if there is none
+func RootCause(trace string) string {
+	lines := strings.Split(strings.TrimSpace(trace), "\n")
+	cause := lines[0]
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Caused by:") {
+			cause = line
+		}
+	}
+	return strings.TrimSpace(cause)
+}
+
+// TruncateTrace returns the first line of a trace, cut to maxTraceLength
+func TruncateTrace(trace string) string {
+	firstLine := strings.SplitN(strings.TrimSpace(trace), "\n", 2)[0]
+	if len(firstLine) > maxTraceLength {
+		return firstLine[:maxTraceLength-3] + "..."
+	}
+	return firstLine
+}
+
+func init() {
+	rootCmd.AddCommand(traceCmd)
+	traceCmd.Flags().BoolVarP(&rootCause, "root-cause", "r", false, "only show the root \"Caused by:\" line of each trace")
+	traceCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "the output format, one of text, json or yaml")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const javaTrace = `org.apache.kafka.connect.errors.ConnectException: task failed
+	at org.apache.kafka.connect.runtime.WorkerTask.run(WorkerTask.java:208)
+Caused by: org.apache.kafka.connect.errors.DataException: bad record
+	at a.b.c(D.java:1)
+Caused by: java.sql.SQLException: connection refused
+	at x.y.z(Z.java:2)
+`
+
+func Test_RootCause(t *testing.T) {
+	assert.Equal(t, "Caused by: java.sql.SQLException: connection refused", RootCause(javaTrace))
+	assert.Equal(t, "java.lang.NullPointerException", RootCause("java.lang.NullPointerException\n\tat a.b.c(D.java:1)"))
+}
+
+func Test_TruncateTrace(t *testing.T) {
+	assert.Equal(t, "org.apache.kafka.connect.errors.ConnectException: task failed", TruncateTrace(javaTrace))
+
+	long := TruncateTrace(strings.Repeat("x", 200))
+	assert.Len(t, long, maxTraceLength)
+	assert.True(t, strings.HasSuffix(long, "..."))
+}
+
+func Test_FailedTraces(t *testing.T) {
+	connectors := map[int]Connector{
+		1: {Id: 1, Name: "b", Details: ConnectorDetails{
+			Connector: ConnectorState{State: "FAILED", WorkerId: "w1", Trace: "java.lang.IllegalStateException"},
+		}},
+		0: {Id: 0, Name: "a", Details: ConnectorDetails{
+			Connector: ConnectorState{State: "RUNNING"},
+			Tasks:     []TaskState{{Id: 0, State: "RUNNING"}, {Id: 1, State: "FAILED", WorkerId: "w2", Trace: javaTrace}},
+		}},
+	}
+
+	traces := FailedTraces(connectors, false)
+	assert.Len(t, traces, 2)
+	assert.Equal(t, "0.1", traces[0].Target())
+	assert.Equal(t, javaTrace, traces[0].Trace)
+	assert.Equal(t, "1", traces[1].Target())
+
+	traces = FailedTraces(connectors, true)
+	assert.Equal(t, "Caused by: java.sql.SQLException: connection refused", traces[0].Trace)
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"strings"
 
+	"github.com/jack-tee/conan/connect"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func GetPersistentFlags(cmd *cobra.Command) (string, string) {
@@ -19,6 +24,30 @@ func GetPersistentFlags(cmd *cobra.Command) (string, string) {
 	return host, port
 }
 
+// GetClient returns a Kafka Connect REST client for the scheme, host and port flags
+// configured with the TLS and auth flags
+func GetClient(cmd *cobra.Command) *connect.Client {
+	client, err := NewClient(cmd)
+	cobra.CheckErr(err)
+	return client
+}
+
+// NewClient returns a Kafka Connect REST client for the scheme, host and port flags
+// or an error if the TLS or authentication flags cannot be applied
+func NewClient(cmd *cobra.Command) (*connect.Client, error) {
+	host, port = GetPersistentFlags(cmd)
+	scheme, err := cmd.Root().PersistentFlags().GetString("scheme")
+	if err != nil {
+		return nil, err
+	}
+
+	client := connect.NewClient(fmt.Sprintf("%s://%s:%s", scheme, host, port))
+	if err := configureClient(client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func HasCaseInsensitivePrefix(s string, prefix string) bool {
 	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
 }
@@ -49,34 +78,66 @@ func AwaitUserConfirm() bool {
 // A connector can be selected by entering a connector id e.g
 // > 4
 // Which will result in a return value of 4
-func AwaitConnectorInput() (bool, bool, []int) {
+func AwaitConnectorInput(in io.Reader) (bool, bool, []int, error) {
 
-	selected := "-1"
-	fmt.Scanln(&selected)
+	selected, err := readInputLine(in)
+	if err != nil {
+		return false, false, nil, err
+	}
 	log.Debug(selected, " selected\n")
 
 	if strings.Contains(selected, "q") {
 		log.Debug("found 'q' in input so exiting")
-		return true, false, nil
+		return true, false, nil, nil
 	}
 
 	if strings.Contains(selected, "all") {
 		log.Debug("found 'all' in input so exiting")
-		return false, true, nil
+		return false, true, nil, nil
 	}
 
-	var connectorIdsSelected []int
+	connectorIdsSelected, err := ParseConnectorIds(selected)
+	if err != nil {
+		return false, false, nil, err
+	}
+
+	log.Debugf("parsed user input from [%s] connectorId: %v", selected, connectorIdsSelected)
+	return false, false, connectorIdsSelected, nil
+}
+
+// readInputLine reads a line of input, a line with more than one value e.g 3.1, 3.4 is read whole
+func readInputLine(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("could not read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ParseConnectorIds parses a comma separated list of connector ids e.g
+// 2,5,7
+func ParseConnectorIds(selected string) ([]int, error) {
+	var connectorIds []int
 
 	for _, c := range strings.Split(selected, ",") {
-		c_int, err := strconv.Atoi(c)
+		c_int, err := strconv.Atoi(strings.TrimSpace(c))
 		if err != nil {
-			panic("could not parse connector id from selected")
+			return nil, fmt.Errorf("could not parse connector id [%s]", c)
 		}
-		connectorIdsSelected = append(connectorIdsSelected, c_int)
+		connectorIds = append(connectorIds, c_int)
 	}
+	return connectorIds, nil
+}
 
-	log.Debugf("parsed user input from [%s] connectorId: %v", selected, connectorIdsSelected)
-	return false, false, connectorIdsSelected
+// IsInteractive reports whether stdin is a terminal that can be prompted for input
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ConnectorTaskId selects a connector, or one of its tasks when TaskId is not -1
+type ConnectorTaskId struct {
+	ConnectorId int
+	TaskId      int
 }
 
 // AwaitConnectorTaskInput prompts the user for input
@@ -86,37 +147,62 @@ func AwaitConnectorInput() (bool, bool, []int) {
 // Or a connector and task can be selected by entering e.g
 // > 5.2
 // Which will result in a return value of 5, 2
-func AwaitConnectorTaskInput() (int, int) {
+// Multiple connectors and tasks can be selected by separating them with commas e.g
+// > 4,5.2,5.3
+func AwaitConnectorTaskInput(in io.Reader) (bool, bool, []ConnectorTaskId, error) {
 
-	selected := "-1"
-	fmt.Scanln(&selected)
+	selected, err := readInputLine(in)
+	if err != nil {
+		return false, false, nil, err
+	}
 	log.Debug(selected, " selected\n")
 
-	var connectorIdSelected int = -1
-	var taskIdSelected int = -1
-	var err error
+	if strings.Contains(selected, "q") {
+		log.Debug("found 'q' in input so exiting")
+		return true, false, nil, nil
+	}
 
-	if strings.Contains(selected, ".") {
-		parts := strings.Split(selected, ".")
+	if strings.Contains(selected, "all") {
+		log.Debug("found 'all' in input so exiting")
+		return false, true, nil, nil
+	}
 
-		connectorIdSelected, err = strconv.Atoi(parts[0])
-		if err != nil {
-			panic("could not parse connector id from selected[0]")
+	connectorTaskIdsSelected, err := ParseConnectorTaskIds(selected)
+	if err != nil {
+		return false, false, nil, err
+	}
+
+	log.Debugf("parsed user input from [%s] connectorTaskIds: %v", selected, connectorTaskIdsSelected)
+	return false, false, connectorTaskIdsSelected, nil
+}
+
+// ParseConnectorTaskIds parses a comma separated list of connector ids
+// and connectorId.taskId pairs e.g
+// 3.1,3.4,5
+func ParseConnectorTaskIds(selected string) ([]ConnectorTaskId, error) {
+	var connectorTaskIds []ConnectorTaskId
+
+	for _, c := range strings.Split(selected, ",") {
+		parts := strings.Split(strings.TrimSpace(c), ".")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("could not parse connector or task id [%s]", c)
 		}
-		taskIdSelected, err = strconv.Atoi(parts[1])
+
+		connectorId, err := strconv.Atoi(parts[0])
 		if err != nil {
-			panic("could not parse task id from selected[1]")
+			return nil, fmt.Errorf("could not parse connector id from [%s]", c)
 		}
 
-	} else {
-
-		connectorIdSelected, err = strconv.Atoi(selected)
-		if err != nil {
-			panic("could not parse connector id from selected")
+		taskId := -1
+		if len(parts) == 2 {
+			taskId, err = strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("could not parse task id from [%s]", c)
+			}
 		}
+		connectorTaskIds = append(connectorTaskIds, ConnectorTaskId{connectorId, taskId})
 	}
-	log.Debugf("parsed user input from [%s] connectorId: %d taskId: %d", selected, connectorIdSelected, taskIdSelected)
-	return connectorIdSelected, taskIdSelected
+	return connectorTaskIds, nil
 }
 
 func FormatPollInterval(pollIntervalMs int) string {
@@ -144,6 +230,7 @@ var Purple = "\033[35m"
 var Cyan = "\033[36m"
 var Gray = "\033[38m"
 var White = "\033[97m"
+var Reverse = "\033[7m"
 
 func FormatState(state string) string {
 	color := ""
@@ -156,6 +243,8 @@ func FormatState(state string) string {
 		color = Red
 	case "UNASSIGNED":
 		color = Gray
+	case "RESTARTING":
+		color = Cyan
 	}
 	return color + state + Reset
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +41,57 @@ func Test_HasCaseInsensitivePrefixRunningMatch(t *testing.T) {
 	res := HasCaseInsensitivePrefix("RUNNING", "runn")
 	assert.True(t, res)
 }
+
+func Test_ParseConnectorIds(t *testing.T) {
+	res, err := ParseConnectorIds("2,5, 7")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 7}, res)
+}
+
+func Test_ParseConnectorIdsInvalid(t *testing.T) {
+	_, err := ParseConnectorIds("2,x")
+	assert.Error(t, err)
+}
+
+func Test_ParseConnectorTaskIds(t *testing.T) {
+	res, err := ParseConnectorTaskIds("3.1,3.4,5")
+	assert.NoError(t, err)
+	assert.Equal(t, []ConnectorTaskId{{3, 1}, {3, 4}, {5, -1}}, res)
+}
+
+func Test_ParseConnectorTaskIdsInvalid(t *testing.T) {
+	_, err := ParseConnectorTaskIds("3.1.2")
+	assert.Error(t, err)
+
+	_, err = ParseConnectorTaskIds("3.x")
+	assert.Error(t, err)
+}
+
+func Test_AwaitConnectorTaskInput(t *testing.T) {
+	// a line with spaces is read whole, as with --tasks
+	quit, all, selected, err := AwaitConnectorTaskInput(strings.NewReader("3.1, 3.4\n"))
+	assert.NoError(t, err)
+	assert.False(t, quit)
+	assert.False(t, all)
+	assert.Equal(t, []ConnectorTaskId{{3, 1}, {3, 4}}, selected)
+
+	quit, _, _, err = AwaitConnectorTaskInput(strings.NewReader("q\n"))
+	assert.NoError(t, err)
+	assert.True(t, quit)
+
+	// invalid input is an error rather than a panic
+	_, _, _, err = AwaitConnectorTaskInput(strings.NewReader("3.x\n"))
+	assert.Error(t, err)
+}
+
+func Test_AwaitConnectorInput(t *testing.T) {
+	_, _, selected, err := AwaitConnectorInput(strings.NewReader(" 2, 5"))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 5}, selected)
+
+	_, _, _, err = AwaitConnectorInput(strings.NewReader("two\n"))
+	assert.Error(t, err)
+
+	_, _, _, err = AwaitConnectorInput(strings.NewReader(""))
+	assert.Error(t, err)
+}
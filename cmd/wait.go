@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jack-tee/conan/connect"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wait        bool          = false
+	waitTimeout time.Duration = 2 * time.Minute
+)
+
+// waitPollInterval is how often the status of the connectors is checked while waiting
+var waitPollInterval = 2 * time.Second
+
+// waitSettlePolls is how many polls a connector has to be seen FAILED, or RUNNING without tasks,
+// before that is taken as its outcome. Straight after an operation the status can still be the one
+// from before it and a connector may legitimately have no tasks.
+const waitSettlePolls = 2
+
+// the outcomes of waiting for a connector to reach a state
+const (
+	WaitReached  = "reached"
+	WaitFailed   = "failed"
+	WaitTimedOut = "timed out"
+	WaitError    = "error"
+)
+
+// WaitResult is the state a connector ended up in after waiting for it to reach TargetState
+type WaitResult struct {
+	ConnectorName string           `json:"connector_name"`
+	TargetState   string           `json:"target_state"`
+	Outcome       string           `json:"outcome"`
+	Duration      time.Duration    `json:"duration"`
+	Details       ConnectorDetails `json:"details"`
+	Error         string           `json:"error,omitempty"`
+
+	polls       int
+	noTaskPolls int
+}
+
+type WaitResults []WaitResult
+
+// addWaitFlags adds the --wait and --timeout flags to commands that change the state of connectors
+func addWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the connectors and their tasks to be RUNNING, or PAUSED when pausing, or for a task to fail")
+	cmd.Flags().DurationVar(&waitTimeout, "timeout", 2*time.Minute, "how long to --wait for before giving up e.g 30s, 5m")
+}
+
+// targetState is the state the connectors are expected to reach after the operation
+func targetState(op Operation) string {
+	if op == Pause {
+		return "PAUSED"
+	}
+	return "RUNNING"
+}
+
+// WaitForState polls the status of each connector until it and all of its tasks reach the targetState,
+// the connector or one of its tasks is still FAILED after the first poll or the timeout passes.
+// A RUNNING connector without tasks reaches RUNNING once it has been seen that way for waitSettlePolls.
+// Connectors that are not found are waited on as a newly created connector may not have a status yet.
+func WaitForState(client *connect.Client, connectorNames []string, targetState string, timeout time.Duration) WaitResults {
+	start := time.Now()
+
+	results := make(WaitResults, len(connectorNames))
+	for i, name := range connectorNames {
+		results[i] = WaitResult{ConnectorName: name, TargetState: targetState}
+	}
+
+	for {
+		pending := 0
+		for i := range results {
+			if results[i].Outcome != "" {
+				continue
+			}
+			results[i].poll(client, start)
+			if results[i].Outcome == "" {
+				pending++
+			}
+		}
+
+		if pending == 0 {
+			return results
+		}
+
+		remaining := timeout - time.Since(start)
+		if remaining <= 0 {
+			for i := range results {
+				if results[i].Outcome == "" {
+					results[i].Outcome = WaitTimedOut
+					results[i].Duration = time.Since(start)
+				}
+			}
+			return results
+		}
+
+		log.Debugf("waiting for %d connectors to be %s", pending, targetState)
+		if remaining < waitPollInterval {
+			time.Sleep(remaining)
+		} else {
+			time.Sleep(waitPollInterval)
+		}
+	}
+}
+
+// poll checks the status of the connector and sets the Outcome once there is one
+func (r *WaitResult) poll(client *connect.Client, start time.Time) {
+	details, err := GetConnectorStatus(client, r.ConnectorName)
+	if connect.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		r.Outcome = WaitError
+		r.Error = err.Error()
+		r.Duration = time.Since(start)
+		return
+	}
+	r.Details = details
+	r.polls++
+
+	if details.Connector.State == "RUNNING" && len(details.Tasks) == 0 {
+		r.noTaskPolls++
+	} else {
+		r.noTaskPolls = 0
+	}
+
+	switch {
+	case r.TargetState != "STOPPED" && details.Failed() && r.polls >= waitSettlePolls:
+		// stopping a FAILED connector is expected to shut down its FAILED tasks so only STOPPED ends the wait
+		r.Outcome = WaitFailed
+	case details.InState(r.TargetState):
+		r.Outcome = WaitReached
+	case r.TargetState == "RUNNING" && r.noTaskPolls >= waitSettlePolls:
+		// the connector has not created any tasks, e.g. there is nothing for it to do yet
+		r.Outcome = WaitReached
+	default:
+		return
+	}
+	r.Duration = time.Since(start)
+}
+
+func (r WaitResult) Reached() bool {
+	return r.Outcome == WaitReached
+}
+
+func (r WaitResult) FormattedOutcome() string {
+	switch r.Outcome {
+	case WaitReached:
+		return Green + r.Outcome + Reset
+	case WaitTimedOut:
+		return Yellow + r.Outcome + Reset
+	}
+	return Red + r.Outcome + Reset
+}
+
+func (r WaitResult) FormattedDuration() string {
+	return r.Duration.Round(time.Second).String()
+}
+
+func (results WaitResults) Reached() int {
+	count := 0
+	for _, r := range results {
+		if r.Reached() {
+			count++
+		}
+	}
+	return count
+}
+
+func (results WaitResults) NotReached() int {
+	return len(results) - results.Reached()
+}
+
+// waitAndReport waits for the connectors to reach the targetState and reports how long it took,
+// it returns false if any of them did not reach it
+func waitAndReport(cmd *cobra.Command, client *connect.Client, connectorNames []string, targetState string) bool {
+	if len(connectorNames) == 0 {
+		return true
+	}
+	fmt.Fprintf(messageWriter(cmd), "Waiting up to %s for %d connectors to be %s.\n", waitTimeout, len(connectorNames), targetState)
+
+	results := WaitForState(client, connectorNames, targetState, waitTimeout)
+	cobra.CheckErr(templates.ExecuteTemplate(messageWriter(cmd), "WaitResultTemplate", results))
+	return results.NotReached() == 0
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jack-tee/conan/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+// statusSequence returns a client for a worker that responds to each status request of a connector
+// with the next of its task states, the last state is repeated
+func statusSequence(t *testing.T, taskStates map[string][]string) *connect.Client {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(strings.TrimPrefix(r.URL.Path, "/connectors/"), "/")[0]
+		states, ok := taskStates[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := int(atomic.AddInt32(&requests, 1)) - 1
+		if n >= len(states) {
+			n = len(states) - 1
+		}
+		fmt.Fprintf(w, `{"name":"%s","connector":{"state":"RUNNING","worker_id":"w1"},"tasks":[{"id":0,"state":"%s","worker_id":"w1"}]}`, name, states[n])
+	}))
+	t.Cleanup(server.Close)
+	return connect.NewClient(server.URL)
+}
+
+func Test_WaitForStateReached(t *testing.T) {
+	waitPollInterval = time.Millisecond
+	client := statusSequence(t, map[string][]string{"a": {"RESTARTING", "UNASSIGNED", "RUNNING"}})
+
+	results := WaitForState(client, []string{"a"}, "RUNNING", time.Second)
+	assert.Equal(t, WaitReached, results[0].Outcome)
+	assert.Equal(t, "RUNNING", results[0].Details.Tasks[0].State)
+	assert.Equal(t, 0, results.NotReached())
+}
+
+func Test_WaitForStateFailed(t *testing.T) {
+	waitPollInterval = time.Millisecond
+	client := statusSequence(t, map[string][]string{"a": {"RESTARTING", "FAILED"}})
+
+	results := WaitForState(client, []string{"a"}, "RUNNING", time.Second)
+	assert.Equal(t, WaitFailed, results[0].Outcome)
+	assert.Equal(t, 1, results.NotReached())
+}
+
+func Test_WaitForStateTimedOut(t *testing.T) {
+	waitPollInterval = time.Millisecond
+	client := statusSequence(t, map[string][]string{"a": {"RUNNING"}})
+
+	// the missing connector is waited on until the timeout
+	results := WaitForState(client, []string{"a", "missing"}, "PAUSED", 20*time.Millisecond)
+	assert.Equal(t, WaitTimedOut, results[0].Outcome)
+	assert.Equal(t, WaitTimedOut, results[1].Outcome)
+	assert.Equal(t, 2, results.NotReached())
+}
+
+func Test_WaitForStateIgnoresFailedFirstPoll(t *testing.T) {
+	waitPollInterval = time.Millisecond
+	// the status from before a restart can still be FAILED
+	client := statusSequence(t, map[string][]string{"a": {"FAILED", "RUNNING"}})
+
+	results := WaitForState(client, []string{"a"}, "RUNNING", time.Second)
+	assert.Equal(t, WaitReached, results[0].Outcome)
+}
+
+func Test_WaitForStateRunningWithoutTasks(t *testing.T) {
+	waitPollInterval = time.Millisecond
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `{"name":"a","connector":{"state":"RUNNING","worker_id":"w1"},"tasks":[]}`)
+	}))
+	defer server.Close()
+
+	results := WaitForState(connect.NewClient(server.URL), []string{"a"}, "RUNNING", time.Second)
+	assert.Equal(t, WaitReached, results[0].Outcome)
+	assert.Equal(t, int32(waitSettlePolls), atomic.LoadInt32(&requests))
+}
+
+func Test_InState(t *testing.T) {
+	running := ConnectorDetails{Connector: ConnectorState{State: "RUNNING"}}
+	assert.False(t, running.InState("RUNNING"), "a RUNNING connector without tasks is still starting")
+
+	running.Tasks = []TaskState{{Id: 0, State: "RUNNING"}, {Id: 1, State: "FAILED"}}
+	assert.False(t, running.InState("RUNNING"))
+	assert.True(t, running.Failed())
+
+	paused := ConnectorDetails{Connector: ConnectorState{State: "PAUSED"}}
+	assert.True(t, paused.InState("PAUSED"))
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var watchInterval int
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [filter]",
+	Short: "Watch the state of the connectors",
+	Long: `Watch the state of the connectors, the list is refreshed every --interval seconds.
+Connectors and tasks whose state changed since the last refresh are highlighted.`,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchInterval < 1 {
+			cobra.CheckErr(fmt.Errorf("--interval must be at least 1 second"))
+		}
+
+		client := GetClient(cmd)
+		clearScreen := term.IsTerminal(int(os.Stdout.Fd()))
+
+		var previous map[string]string
+		for {
+			connectors, err := FilterConnectors(client, args)
+			view := NewWatchView(connectors, previous, err)
+			view.Interval = watchInterval
+
+			if clearScreen {
+				fmt.Fprint(cmd.OutOrStdout(), "\033[H\033[2J")
+			}
+			cobra.CheckErr(templates.ExecuteTemplate(cmd.OutOrStdout(), "WatchTemplate", view))
+
+			// keep the states from before a failed refresh so their changes are still highlighted
+			if err == nil {
+				previous = connectorStates(connectors)
+			}
+			time.Sleep(time.Duration(watchInterval) * time.Second)
+		}
+	},
+}
+
+// WatchView is a refresh of the watched connectors
+type WatchView struct {
+	ConnectorRows
+	ConnectorCounts []StateCount
+	TaskCounts      []StateCount
+	Refreshed       time.Time
+	Interval        int
+	Error           error
+}
+
+type StateCount struct {
+	State string
+	Count int
+}
+
+// NewWatchView compares the states of the connectors and their tasks with their previous states,
+// there are no changes on the first refresh when previous is nil
+func NewWatchView(connectors map[int]Connector, previous map[string]string, err error) WatchView {
+	view := WatchView{ConnectorRows: ConnectorRows{Connectors: connectors, Changed: make(map[string]bool)}, Refreshed: time.Now(), Error: err}
+
+	connectorCounts := make(map[string]int)
+	taskCounts := make(map[string]int)
+	for _, c := range connectors {
+		connectorCounts[c.Details.Connector.State]++
+		for _, t := range c.Details.Tasks {
+			taskCounts[t.State]++
+		}
+	}
+	view.ConnectorCounts = sortedCounts(connectorCounts)
+	view.TaskCounts = sortedCounts(taskCounts)
+
+	if previous == nil {
+		return view
+	}
+	for key, state := range connectorStates(connectors) {
+		if previous[key] != state {
+			view.Changed[key] = true
+		}
+	}
+	return view
+}
+
+// connectorStates returns the state of each connector by name and each task by name/taskId
+func connectorStates(connectors map[int]Connector) map[string]string {
+	states := make(map[string]string)
+	for _, c := range connectors {
+		states[c.Name] = c.Details.Connector.State
+		for _, t := range c.Details.Tasks {
+			states[taskKey(c.Name, t.Id)] = t.State
+		}
+	}
+	return states
+}
+
+func taskKey(connectorName string, taskId int) string {
+	return connectorName + "/" + strconv.Itoa(taskId)
+}
+
+func sortedCounts(counts map[string]int) []StateCount {
+	sorted := make([]StateCount, 0, len(counts))
+	for state, count := range counts {
+		sorted = append(sorted, StateCount{state, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].State < sorted[j].State })
+	return sorted
+}
+
+// ConnectorRows are the connectors listed by the ListTemplate and WatchTemplate,
+// the states in Changed are highlighted
+type ConnectorRows struct {
+	Connectors map[int]Connector
+	Changed    map[string]bool
+}
+
+// ConnectorState returns the formatted state of the connector, highlighted if it changed
+func (v ConnectorRows) ConnectorState(c Connector) string {
+	return v.highlight(c.Name, c.Details.Connector.FormattedState())
+}
+
+// TaskState returns the formatted state of the task, highlighted if it changed
+func (v ConnectorRows) TaskState(c Connector, t TaskState) string {
+	return v.highlight(taskKey(c.Name, t.Id), t.FormattedState())
+}
+
+func (v ConnectorRows) highlight(key string, formattedState string) string {
+	if v.Changed[key] {
+		return Reverse + formattedState
+	}
+	return formattedState
+}
+
+func (s StateCount) FormattedState() string {
+	return FormatState(s.State)
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringVarP(&taskFilter, "task-filter", "t", "", "a substring to filter task summaries by")
+	watchCmd.Flags().StringVarP(&stateFilter, "state-filter", "s", "", "filter to connectors / tasks in this state")
+	watchCmd.Flags().IntVarP(&watchInterval, "interval", "n", 5, "seconds to wait between refreshes")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func watchedConnectors(connectorState string, taskState string) map[int]Connector {
+	return map[int]Connector{
+		0: {Id: 0, Name: "a", Details: ConnectorDetails{
+			Connector: ConnectorState{State: connectorState},
+			Tasks:     []TaskState{{Id: 0, State: taskState}, {Id: 1, State: "RUNNING"}},
+		}},
+		1: {Id: 1, Name: "b", Details: ConnectorDetails{
+			Connector: ConnectorState{State: "RUNNING"},
+			Tasks:     []TaskState{{Id: 0, State: "RUNNING"}},
+		}},
+	}
+}
+
+func Test_NewWatchViewFirstRefresh(t *testing.T) {
+	view := NewWatchView(watchedConnectors("RUNNING", "FAILED"), nil, nil)
+	assert.Empty(t, view.Changed)
+	assert.Equal(t, []StateCount{{"RUNNING", 2}}, view.ConnectorCounts)
+	assert.Equal(t, []StateCount{{"FAILED", 1}, {"RUNNING", 2}}, view.TaskCounts)
+}
+
+func Test_NewWatchViewChanges(t *testing.T) {
+	previous := connectorStates(watchedConnectors("RUNNING", "RUNNING"))
+
+	connectors := watchedConnectors("PAUSED", "FAILED")
+	view := NewWatchView(connectors, previous, nil)
+	assert.Equal(t, map[string]bool{"a": true, "a/0": true}, view.Changed)
+	assert.Contains(t, view.ConnectorState(connectors[0]), Reverse)
+	assert.NotContains(t, view.TaskState(connectors[0], connectors[0].Details.Tasks[1]), Reverse)
+	assert.NotContains(t, view.ConnectorState(connectors[1]), Reverse)
+}
+
+func Test_WatchTemplate(t *testing.T) {
+	toggleDebug(nil, nil)
+	view := NewWatchView(watchedConnectors("RUNNING", "FAILED"), nil, nil)
+	view.Interval = 5
+
+	var out bytes.Buffer
+	assert.NoError(t, templates.ExecuteTemplate(&out, "WatchTemplate", view))
+	assert.Contains(t, out.String(), "Every 5s: conan watch")
+	assert.Contains(t, out.String(), "Total: 2 Connectors")
+}
+
+func Test_WatchTemplateHighlightsChanges(t *testing.T) {
+	toggleDebug(nil, nil)
+	previous := connectorStates(watchedConnectors("RUNNING", "RUNNING"))
+	view := NewWatchView(watchedConnectors("PAUSED", "RUNNING"), previous, nil)
+
+	var out bytes.Buffer
+	assert.NoError(t, templates.ExecuteTemplate(&out, "WatchTemplate", view))
+	assert.Contains(t, out.String(), Reverse+FormatState("PAUSED"))
+
+	// the ListTemplate shares the connector rows without highlighting
+	out.Reset()
+	assert.NoError(t, templates.ExecuteTemplate(&out, "ListTemplate", view.Connectors))
+	assert.Contains(t, out.String(), "LIST: 2 Connectors")
+	assert.NotContains(t, out.String(), Reverse)
+}
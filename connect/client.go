@@ -0,0 +1,101 @@
+// Package connect is a client for the Kafka Connect REST API.
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client makes requests to a single Kafka Connect REST endpoint e.g http://localhost:8083
+// If Token is set it is sent as a bearer token, otherwise if Username is set basic auth is used.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Username   string
+	Password   string
+	Token      string
+}
+
+// Response holds the HTTP status of a request made by the Client.
+type Response struct {
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+}
+
+// NewClient returns a Client for the given base URL using the default http client.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Do sends a request to path, which is relative to the BaseURL.
+// If in is not nil it is sent as the JSON request body, if out is not nil
+// a successful response body is decoded into it.
+// The returned Response is not nil whenever the worker responded, including
+// when the status code is an error, in which case the error is an *APIError.
+func (c *Client) Do(method string, path string, in interface{}, out interface{}) (*Response, error) {
+	url := c.BaseURL + path
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode request body for %s: %w", url, err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	log.Debug(method, " ", url)
+	httpResp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &Response{Status: httpResp.Status, StatusCode: httpResp.StatusCode}
+	log.Debug("got response status: ", resp.Status, " from ", url)
+
+	bodyBytes, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, fmt.Errorf("could not read response from %s: %w", url, err)
+	}
+
+	if httpResp.StatusCode >= 300 {
+		return resp, newAPIError(method, url, httpResp, bodyBytes)
+	}
+
+	if out != nil && len(bodyBytes) > 0 {
+		if err := json.Unmarshal(bodyBytes, out); err != nil {
+			return resp, fmt.Errorf("could not decode response from %s: %w", url, err)
+		}
+	}
+	return resp, nil
+}
+
+func (c *Client) get(path string, out interface{}) error {
+	_, err := c.Do(http.MethodGet, path, nil, out)
+	return err
+}
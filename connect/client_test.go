@@ -0,0 +1,70 @@
+package connect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL)
+}
+
+func Test_ListConnectors(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/connectors", r.URL.Path)
+		w.Write([]byte(`["b-connector","a-connector"]`))
+	})
+
+	connectors, err := client.ListConnectors()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b-connector", "a-connector"}, connectors)
+}
+
+func Test_ConnectorStatusNotFound(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":404,"message":"Connector missing not found"}`))
+	})
+
+	_, err := client.ConnectorStatus("missing")
+	assert.True(t, IsNotFound(err))
+
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, 404, apiErr.ErrorCode)
+	assert.Equal(t, "Connector missing not found", apiErr.Message)
+}
+
+func Test_ErrorWithoutConnectBody(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("bad gateway"))
+	})
+
+	resp, err := client.PauseConnector("my-connector")
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.EqualError(t, err, "PUT "+client.BaseURL+"/connectors/my-connector/pause returned 502 Bad Gateway: bad gateway")
+}
+
+func Test_InvalidJsonIsAnError(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`not json`))
+	})
+
+	_, err := client.ConnectorConfig("my-connector")
+	assert.Error(t, err)
+}
+
+func Test_ListConnectorsExpandedNotSupported(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`["a-connector"]`))
+	})
+
+	_, err := client.ListConnectorsExpanded()
+	assert.Equal(t, ErrExpandNotSupported, err)
+}
@@ -0,0 +1,216 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ConnectorStatus is the response of GET /connectors/{name}/status
+type ConnectorStatus struct {
+	Name      string         `json:"name"`
+	Connector ConnectorState `json:"connector"`
+	Tasks     []TaskState    `json:"tasks"`
+	Type      string         `json:"type"`
+}
+
+type ConnectorState struct {
+	State    string `json:"state"`
+	WorkerId string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
+}
+
+type TaskState struct {
+	Id       int    `json:"id"`
+	State    string `json:"state"`
+	WorkerId string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
+}
+
+// TaskInfo is an element of the response of GET /connectors/{name}/tasks
+type TaskInfo struct {
+	Id     TaskId            `json:"id"`
+	Config map[string]string `json:"config"`
+}
+
+type TaskId struct {
+	Connector string `json:"connector"`
+	Task      int    `json:"task"`
+}
+
+// ValidationResponse is the response of PUT /connector-plugins/{class}/config/validate
+type ValidationResponse struct {
+	Name       string                    `json:"name"`
+	ErrorCount int                       `json:"error_count"`
+	Configs    []ValidationResponseField `json:"configs"`
+}
+
+type ValidationResponseField struct {
+	Definition ValidationResponseFieldDefinition `json:"definition"`
+	Value      ValidationResponseFieldValue      `json:"value"`
+}
+
+// ValidationResponseFieldDefinition describes a config key of the plugin, DefaultValue is nil when there is no default
+type ValidationResponseFieldDefinition struct {
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	DefaultValue *string `json:"default_value"`
+}
+
+type ValidationResponseFieldValue struct {
+	Name   string   `json:"name"`
+	Errors []string `json:"errors"`
+}
+
+func connectorPath(name string, parts ...interface{}) string {
+	path := "/connectors/" + url.PathEscape(name)
+	for _, p := range parts {
+		path += fmt.Sprintf("/%v", p)
+	}
+	return path
+}
+
+// ListConnectors returns the names of the deployed connectors.
+func (c *Client) ListConnectors() ([]string, error) {
+	var connectors []string
+	err := c.get("/connectors", &connectors)
+	return connectors, err
+}
+
+// ConnectorStatus returns the state of a connector and its tasks.
+func (c *Client) ConnectorStatus(name string) (ConnectorStatus, error) {
+	var status ConnectorStatus
+	err := c.get(connectorPath(name, "status"), &status)
+	return status, err
+}
+
+// ConnectorConfig returns the config of a connector.
+func (c *Client) ConnectorConfig(name string) (map[string]string, error) {
+	var config map[string]string
+	err := c.get(connectorPath(name, "config"), &config)
+	return config, err
+}
+
+// ConnectorTasks returns the config of each of a connector's tasks.
+func (c *Client) ConnectorTasks(name string) ([]TaskInfo, error) {
+	var tasks []TaskInfo
+	err := c.get(connectorPath(name, "tasks"), &tasks)
+	return tasks, err
+}
+
+// PutConnectorConfig creates the connector or updates its config if it already exists.
+func (c *Client) PutConnectorConfig(name string, config map[string]string) (*Response, error) {
+	return c.Do(http.MethodPut, connectorPath(name, "config"), config, nil)
+}
+
+// ValidateConfig validates config against the connector plugin class.
+func (c *Client) ValidateConfig(pluginClass string, config map[string]string) (ValidationResponse, error) {
+	var validation ValidationResponse
+	path := fmt.Sprintf("/connector-plugins/%s/config/validate", url.PathEscape(pluginClass))
+	_, err := c.Do(http.MethodPut, path, config, &validation)
+	return validation, err
+}
+
+func (c *Client) PauseConnector(name string) (*Response, error) {
+	return c.Do(http.MethodPut, connectorPath(name, "pause"), nil, nil)
+}
+
+func (c *Client) ResumeConnector(name string) (*Response, error) {
+	return c.Do(http.MethodPut, connectorPath(name, "resume"), nil, nil)
+}
+
+func (c *Client) RestartConnector(name string) (*Response, error) {
+	return c.Do(http.MethodPost, connectorPath(name, "restart"), nil, nil)
+}
+
+func (c *Client) DeleteConnector(name string) (*Response, error) {
+	return c.Do(http.MethodDelete, connectorPath(name), nil, nil)
+}
+
+func (c *Client) RestartTask(name string, taskId int) (*Response, error) {
+	return c.Do(http.MethodPost, connectorPath(name, "tasks", taskId, "restart"), nil, nil)
+}
+
+// ConnectorInfo is the response of GET /connectors/{name}
+type ConnectorInfo struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config"`
+	Tasks  []TaskId          `json:"tasks"`
+	Type   string            `json:"type"`
+}
+
+// ExpandedConnector is an element of the response of GET /connectors?expand=status&expand=info
+type ExpandedConnector struct {
+	Status ConnectorStatus `json:"status"`
+	Info   ConnectorInfo   `json:"info"`
+}
+
+// ErrExpandNotSupported is returned by ListConnectorsExpanded when the worker
+// predates the expand query parameter (added in Kafka 2.3) and returned a plain list of names.
+var ErrExpandNotSupported = errors.New("the Kafka Connect worker does not support ?expand")
+
+// ListConnectorsExpanded returns the status and info of every connector in a single request.
+func (c *Client) ListConnectorsExpanded() (map[string]ExpandedConnector, error) {
+	var raw json.RawMessage
+	if err := c.get("/connectors?expand=status&expand=info", &raw); err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		return nil, ErrExpandNotSupported
+	}
+
+	var connectors map[string]ExpandedConnector
+	if err := json.Unmarshal(raw, &connectors); err != nil {
+		return nil, fmt.Errorf("could not decode expanded connectors: %w", err)
+	}
+	return connectors, nil
+}
+
+// RestartConnectorAndTasks restarts the connector and its tasks in a single request (Kafka 3.0+).
+// With onlyFailed only the connector and tasks that are FAILED are restarted.
+// The returned status has the state of the connector and tasks after the restart was requested,
+// they are RESTARTING when they are being restarted.
+func (c *Client) RestartConnectorAndTasks(name string, onlyFailed bool) (ConnectorStatus, *Response, error) {
+	var status ConnectorStatus
+	path := fmt.Sprintf("%s?includeTasks=true&onlyFailed=%t", connectorPath(name, "restart"), onlyFailed)
+	resp, err := c.Do(http.MethodPost, path, nil, &status)
+	return status, resp, err
+}
+
+// ConnectorOffsets is the response of GET /connectors/{name}/offsets and the body of PATCH /connectors/{name}/offsets (Kafka 3.6+)
+type ConnectorOffsets struct {
+	Offsets []ConnectorOffset `json:"offsets"`
+}
+
+// ConnectorOffset is the offset of a source partition or a sink topic partition,
+// a nil Offset in a PATCH resets the offset of the partition
+type ConnectorOffset struct {
+	Partition map[string]interface{} `json:"partition"`
+	Offset    map[string]interface{} `json:"offset"`
+}
+
+// StopConnector stops the connector and shuts down its tasks without deleting its config (Kafka 3.5+)
+func (c *Client) StopConnector(name string) (*Response, error) {
+	return c.Do(http.MethodPut, connectorPath(name, "stop"), nil, nil)
+}
+
+// ConnectorOffsets gets the committed offsets of the connector.
+func (c *Client) ConnectorOffsets(name string) (ConnectorOffsets, error) {
+	var offsets ConnectorOffsets
+	err := c.get(connectorPath(name, "offsets"), &offsets)
+	return offsets, err
+}
+
+// ResetConnectorOffsets deletes all of the committed offsets of the connector, it must be STOPPED.
+func (c *Client) ResetConnectorOffsets(name string) (*Response, error) {
+	return c.Do(http.MethodDelete, connectorPath(name, "offsets"), nil, nil)
+}
+
+// AlterConnectorOffsets updates the committed offsets of the partitions, it must be STOPPED.
+func (c *Client) AlterConnectorOffsets(name string, offsets ConnectorOffsets) (*Response, error) {
+	return c.Do(http.MethodPatch, connectorPath(name, "offsets"), offsets, nil)
+}
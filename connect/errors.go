@@ -0,0 +1,60 @@
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the Kafka Connect REST API responds with an error status.
+// ErrorCode and Message are decoded from the standard Connect error body
+// e.g {"error_code":404,"message":"Connector my-connector not found"}
+type APIError struct {
+	Method     string
+	URL        string
+	Status     string
+	StatusCode int
+	ErrorCode  int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s returned %s: %s", e.Method, e.URL, e.Status, e.Message)
+	}
+	return fmt.Sprintf("%s %s returned %s", e.Method, e.URL, e.Status)
+}
+
+func newAPIError(method string, url string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{}
+	if err := json.Unmarshal(body, apiErr); err != nil {
+		// not a Connect error body so fall back to the raw response
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	apiErr.Method = method
+	apiErr.URL = url
+	apiErr.Status = resp.Status
+	apiErr.StatusCode = resp.StatusCode
+	return apiErr
+}
+
+// IsNotFound reports whether err is an APIError with a 404 status.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an APIError with a 409 status,
+// which Connect returns while a rebalance is in progress.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == statusCode
+	}
+	return false
+}
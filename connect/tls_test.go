@@ -0,0 +1,59 @@
+package connect
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TLSWithCABundleAndBasicAuth(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "conan" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`["a-connector"]`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, ioutil.WriteFile(caFile, ca, 0600))
+
+	tlsConfig, err := NewTLSConfig(caFile, "", "")
+	assert.NoError(t, err)
+
+	client := NewClient(server.URL)
+	client.HTTPClient = NewHTTPClient(tlsConfig)
+
+	_, err = client.ListConnectors()
+	assert.Error(t, err)
+
+	client.Username = "conan"
+	client.Password = "secret"
+	connectors, err := client.ListConnectors()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a-connector"}, connectors)
+}
+
+func Test_BearerToken(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`[]`))
+	})
+	client.Token = "my-token"
+
+	_, err := client.ListConnectors()
+	assert.NoError(t, err)
+}
+
+func Test_TLSConfigRequiresCertAndKey(t *testing.T) {
+	_, err := NewTLSConfig("", "client.pem", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package connect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ServerInfo is the response of GET /
+type ServerInfo struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	KafkaClusterId string `json:"kafka_cluster_id"`
+}
+
+// ServerInfo returns the version of the Kafka Connect worker.
+func (c *Client) ServerInfo() (ServerInfo, error) {
+	var info ServerInfo
+	err := c.get("/", &info)
+	return info, err
+}
+
+// KafkaVersion returns the major and minor Kafka version of the worker.
+// Confluent Platform versions e.g 7.4.0-ccs are mapped to the Kafka version they are based on.
+func (s ServerInfo) KafkaVersion() (int, int, bool) {
+	version := s.Version
+	confluent := false
+	if i := strings.Index(version, "-"); i >= 0 {
+		suffix := version[i+1:]
+		confluent = strings.HasPrefix(suffix, "ccs") || strings.HasPrefix(suffix, "ce")
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if confluent {
+		switch {
+		case major <= 5:
+			// 5.0 is based on 2.0
+			return 2, minor, true
+		case major == 6:
+			// 6.0 is based on 2.6
+			return 2, minor + 6, true
+		default:
+			// 7.0 is based on 3.0
+			return major - 4, minor, true
+		}
+	}
+	return major, minor, true
+}
+
+// AtLeast reports whether the worker's Kafka version is at least major.minor,
+// it is false if the version cannot be parsed.
+func (s ServerInfo) AtLeast(major int, minor int) bool {
+	kafkaMajor, kafkaMinor, ok := s.KafkaVersion()
+	if !ok {
+		return false
+	}
+	return kafkaMajor > major || (kafkaMajor == major && kafkaMinor >= minor)
+}
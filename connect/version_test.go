@@ -0,0 +1,30 @@
+package connect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KafkaVersion(t *testing.T) {
+	for version, expected := range map[string][2]int{
+		"3.6.1":          {3, 6},
+		"2.8.0":          {2, 8},
+		"7.4.0-ccs":      {3, 4},
+		"6.1.1-ce":       {2, 7},
+		"5.5.0-ccs":      {2, 5},
+		"3.7.0-SNAPSHOT": {3, 7},
+	} {
+		major, minor, ok := ServerInfo{Version: version}.KafkaVersion()
+		assert.True(t, ok, version)
+		assert.Equal(t, expected, [2]int{major, minor}, version)
+	}
+}
+
+func Test_AtLeast(t *testing.T) {
+	assert.True(t, ServerInfo{Version: "3.0.0"}.AtLeast(3, 0))
+	assert.True(t, ServerInfo{Version: "7.0.1-ccs"}.AtLeast(3, 0))
+	assert.False(t, ServerInfo{Version: "6.2.0-ccs"}.AtLeast(3, 0))
+	assert.False(t, ServerInfo{Version: "2.8.1"}.AtLeast(3, 0))
+	assert.False(t, ServerInfo{Version: "unknown"}.AtLeast(3, 0))
+}